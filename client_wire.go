@@ -4,26 +4,49 @@
 package dicedb
 
 import (
-	"fmt"
+	"context"
 	"net"
+	"strconv"
 	"time"
 
 	"github.com/sevenDatabase/SevenDB-go/internal"
 	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
 )
 
+// Dialer opens the network connection a ClientWire runs on. It has the same
+// shape as net.Dialer.DialContext so wrappers such as faultconn can be
+// inserted between the client and the socket.
+type Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+
 type ClientWire struct {
 	*internal.ProtobufTCPWire
+	conn   net.Conn
+	closed bool
+	caps   Capabilities
+	// endpoint is the index of the client endpoint the connection went to.
+	endpoint int
+	// mux is set when the connection negotiated request IDs.
+	mux *mux
 }
 
 func NewClientWire(maxMsgSize int, host string, port int) (*ClientWire, *wire.WireError) {
-	addr := fmt.Sprintf("%s:%d", host, port)
-	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	return NewClientWireWithDialer(maxMsgSize, host, port, defaultDialer)
+}
+
+func NewClientWireWithDialer(maxMsgSize int, host string, port int, dial Dialer) (*ClientWire, *wire.WireError) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := dial(ctx, "tcp", addr)
 	if err != nil {
 		return nil, &wire.WireError{Kind: wire.NotEstablished, Cause: err}
 	}
 	w := &ClientWire{
 		ProtobufTCPWire: internal.NewProtobufTCPWire(maxMsgSize, conn),
+		conn:            conn,
 	}
 
 	return w, nil
@@ -40,6 +63,36 @@ func (cw *ClientWire) Receive() (*wire.Result, *wire.WireError) {
 	return resp, err
 }
 
+// SendBatch sends cmds in a single write.
+func (cw *ClientWire) SendBatch(cmds []*wire.Command) *wire.WireError {
+	msgs := make([]proto.Message, len(cmds))
+	for i, cmd := range cmds {
+		msgs[i] = cmd
+	}
+
+	return cw.ProtobufTCPWire.SendBatch(msgs)
+}
+
+// SendWithID sends cmd tagged with a request ID, once request IDs were
+// negotiated.
+func (cw *ClientWire) SendWithID(id uint32, cmd *wire.Command) *wire.WireError {
+	return cw.ProtobufTCPWire.SendWithID(id, cmd)
+}
+
+// ReceiveWithID receives a result and the request ID of the command it
+// answers.
+func (cw *ClientWire) ReceiveWithID() (uint32, *wire.Result, *wire.WireError) {
+	resp := &wire.Result{}
+	id, err := cw.ProtobufTCPWire.ReceiveWithID(resp)
+
+	return id, resp, err
+}
+
 func (cw *ClientWire) Close() {
 	cw.ProtobufTCPWire.Close()
 }
+
+func defaultDialer(ctx context.Context, network, addr string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, network, addr)
+}
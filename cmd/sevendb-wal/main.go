@@ -0,0 +1,457 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+// Command sevendb-wal inspects and manipulates SevenDB WAL directories.
+//
+//	sevendb-wal ls     [-json] DIR
+//	sevendb-wal cat    [-json] [-from LSN] [-to LSN] DIR
+//	sevendb-wal verify [-json] DIR
+//	sevendb-wal stats  [-json] [-from LSN] [-to LSN] DIR
+//	sevendb-wal cut    [-json] -from LSN [-to LSN] -out DIR DIR
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sevenDatabase/SevenDB-go/wal"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+type command struct {
+	usage string
+	run   func(args []string, out, errOut io.Writer) error
+}
+
+var commands = map[string]command{
+	"ls":     {"list segments with their LSN ranges and sizes", runLs},
+	"cat":    {"print decoded elements", runCat},
+	"verify": {"check every record against its checksum", runVerify},
+	"stats":  {"print a command histogram and the record rate", runStats},
+	"cut":    {"copy an LSN range into a new WAL directory", runCut},
+}
+
+// errCorrupt makes verify exit with a failure status after printing its report.
+var errCorrupt = errors.New("wal is corrupt")
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "sevendb-wal:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: sevendb-wal <command> [flags] DIR")
+	fmt.Fprintln(os.Stderr)
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-7s %s\n", name, commands[name].usage)
+	}
+}
+
+type flags struct {
+	*flag.FlagSet
+	json bool
+	from uint64
+	to   uint64
+}
+
+func newFlags(name string, lsnRange bool) *flags {
+	f := &flags{FlagSet: flag.NewFlagSet(name, flag.ExitOnError)}
+	f.BoolVar(&f.json, "json", false, "print JSON instead of text")
+	if lsnRange {
+		f.Uint64Var(&f.from, "from", 0, "first LSN, inclusive")
+		f.Uint64Var(&f.to, "to", 0, "last LSN, inclusive; 0 reads to the end")
+	}
+
+	return f
+}
+
+// isSet reports whether the flag called name was given on the command line.
+func (f *flags) isSet(name string) bool {
+	set := false
+	f.Visit(func(fl *flag.Flag) {
+		if fl.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
+// dir parses args and returns the WAL directory argument.
+func (f *flags) dir(args []string) (string, error) {
+	if err := f.Parse(args); err != nil {
+		return "", err
+	}
+
+	if f.NArg() != 1 {
+		return "", fmt.Errorf("%s: expected exactly one WAL directory", f.Name())
+	}
+
+	return f.Arg(0), nil
+}
+
+// each calls fn for every element in the selected LSN range. With
+// RecoverSkip, damaged records are reported to errOut and their number
+// returned.
+func (f *flags) each(dir string, policy wal.RecoveryPolicy, errOut io.Writer, fn func(e *wal.Element) error) (int, error) {
+	r, err := wal.NewReader(dir, f.from, wal.WithRecovery(policy))
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	skipped := r.Corruptions()
+	for _, c := range skipped {
+		fmt.Fprintf(errOut, "skipped damaged record in %s at offset %d: %s\n", c.Segment, c.Offset, c.Err)
+	}
+
+	for {
+		e, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return len(skipped), nil
+		}
+		if err != nil {
+			return len(skipped), err
+		}
+
+		if f.to > 0 && e.Lsn > f.to {
+			return len(skipped), nil
+		}
+
+		if err := fn(e); err != nil {
+			return len(skipped), err
+		}
+	}
+}
+
+func runLs(args []string, out, errOut io.Writer) error {
+	f := newFlags("ls", false)
+	dir, err := f.dir(args)
+	if err != nil {
+		return err
+	}
+
+	infos, err := wal.Segments(dir)
+	if err != nil {
+		return err
+	}
+
+	if f.json {
+		type segmentJSON struct {
+			Path        string `json:"path"`
+			FirstLSN    uint64 `json:"first_lsn"`
+			LastLSN     uint64 `json:"last_lsn"`
+			Records     int    `json:"records"`
+			Size        int64  `json:"size"`
+			Corruptions int    `json:"corruptions"`
+		}
+
+		segments := make([]segmentJSON, 0, len(infos))
+		for _, info := range infos {
+			segments = append(segments, segmentJSON{info.Path, info.FirstLSN, info.LastLSN, info.Records, info.Size, len(info.Corruptions)})
+		}
+
+		return printJSON(out, segments)
+	}
+
+	fmt.Fprintf(out, "%-40s %20s %20s %10s %12s\n", "SEGMENT", "FIRST LSN", "LAST LSN", "RECORDS", "SIZE")
+	for _, info := range infos {
+		fmt.Fprintf(out, "%-40s %20d %20d %10d %12d\n", info.Path, info.FirstLSN, info.LastLSN, info.Records, info.Size)
+	}
+
+	return nil
+}
+
+type elementJSON struct {
+	LSN       uint64    `json:"lsn"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Command   string    `json:"command,omitempty"`
+	Cmd       string    `json:"cmd,omitempty"`
+	Args      []string  `json:"args,omitempty"`
+	Payload   []byte    `json:"payload,omitempty"`
+}
+
+func runCat(args []string, out, errOut io.Writer) error {
+	f := newFlags("cat", true)
+	dir, err := f.dir(args)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(out)
+	_, err = f.each(dir, wal.RecoverSkip, errOut, func(e *wal.Element) error {
+		element := elementJSON{
+			LSN:       e.Lsn,
+			Timestamp: time.Unix(0, e.Timestamp).UTC(),
+			Type:      e.ElementType.String(),
+		}
+
+		if e.ElementType == wal.ElementType_ELEMENT_TYPE_COMMAND {
+			if cmd, err := decodeCommand(e); err == nil {
+				element.Command = commandText(cmd)
+				element.Cmd = cmd.Cmd
+				element.Args = cmd.Args
+			} else {
+				element.Payload = e.Payload
+			}
+		}
+
+		if f.json {
+			return enc.Encode(element)
+		}
+
+		text := element.Command
+		if element.Payload != nil {
+			text = fmt.Sprintf("<undecodable payload %x>", element.Payload)
+		}
+		fmt.Fprintf(out, "%d\t%s\t%s\t%s\n", element.LSN, element.Timestamp.Format(time.RFC3339Nano), element.Type, text)
+
+		return nil
+	})
+
+	return err
+}
+
+func runVerify(args []string, out, errOut io.Writer) error {
+	f := newFlags("verify", false)
+	dir, err := f.dir(args)
+	if err != nil {
+		return err
+	}
+
+	infos, err := wal.Segments(dir)
+	if err != nil {
+		return err
+	}
+
+	type corruptionJSON struct {
+		Segment string `json:"segment"`
+		Offset  int64  `json:"offset"`
+		Error   string `json:"error"`
+	}
+
+	records := 0
+	corruptions := []corruptionJSON{}
+	for _, info := range infos {
+		records += info.Records
+		for _, c := range info.Corruptions {
+			corruptions = append(corruptions, corruptionJSON{c.Segment, c.Offset, c.Err.Error()})
+		}
+	}
+
+	if f.json {
+		if err := printJSON(out, struct {
+			Segments    int              `json:"segments"`
+			Records     int              `json:"records"`
+			Corruptions []corruptionJSON `json:"corruptions"`
+		}{len(infos), records, corruptions}); err != nil {
+			return err
+		}
+	} else {
+		for _, c := range corruptions {
+			fmt.Fprintf(out, "%s: offset %d: %s\n", c.Segment, c.Offset, c.Error)
+		}
+		fmt.Fprintf(out, "%d segments, %d good records, %d corrupt records\n", len(infos), records, len(corruptions))
+	}
+
+	if len(corruptions) > 0 {
+		return errCorrupt
+	}
+
+	return nil
+}
+
+func runStats(args []string, out, errOut io.Writer) error {
+	f := newFlags("stats", true)
+	dir, err := f.dir(args)
+	if err != nil {
+		return err
+	}
+
+	var records, noops, undecodable int
+	var first, last int64
+	histogram := map[string]int{}
+	skipped, err := f.each(dir, wal.RecoverSkip, errOut, func(e *wal.Element) error {
+		if records == 0 {
+			first = e.Timestamp
+		}
+		last = e.Timestamp
+		records++
+
+		if e.ElementType == wal.ElementType_ELEMENT_TYPE_NOOP {
+			noops++
+			return nil
+		}
+
+		cmd, err := decodeCommand(e)
+		if err != nil {
+			undecodable++
+			return nil
+		}
+		histogram[strings.ToUpper(cmd.Cmd)]++
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	span := time.Duration(last - first)
+	var rate float64
+	if span > 0 {
+		rate = float64(records) / span.Seconds()
+	}
+
+	if f.json {
+		return printJSON(out, struct {
+			Records          int            `json:"records"`
+			Skipped          int            `json:"skipped"`
+			Noops            int            `json:"noops"`
+			Undecodable      int            `json:"undecodable"`
+			Span             string         `json:"span"`
+			RecordsPerSecond float64        `json:"records_per_second"`
+			Commands         map[string]int `json:"commands"`
+		}{records, skipped, noops, undecodable, span.String(), rate, histogram})
+	}
+
+	fmt.Fprintf(out, "records: %d (%d noops, %d undecodable, %d damaged skipped)\n", records, noops, undecodable, skipped)
+	fmt.Fprintf(out, "span: %s, %.2f records/s\n", span, rate)
+
+	names := make([]string, 0, len(histogram))
+	for name := range histogram {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if histogram[names[i]] != histogram[names[j]] {
+			return histogram[names[i]] > histogram[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		fmt.Fprintf(out, "%-12s %d\n", name, histogram[name])
+	}
+
+	return nil
+}
+
+func runCut(args []string, out, errOut io.Writer) error {
+	f := newFlags("cut", true)
+	outDir := f.String("out", "", "directory of the new WAL, which must not hold segments yet")
+	dir, err := f.dir(args)
+	if err != nil {
+		return err
+	}
+
+	if !f.isSet("from") {
+		return errors.New("cut: -from is required")
+	}
+
+	if *outDir == "" {
+		return errors.New("cut: -out is required")
+	}
+
+	if existing, err := wal.Segments(*outDir); err == nil && len(existing) > 0 {
+		return fmt.Errorf("cut: %s already holds a WAL", *outDir)
+	}
+
+	w, err := wal.OpenWriter(*outDir, wal.Options{SyncPolicy: wal.SyncNever})
+	if err != nil {
+		return err
+	}
+
+	var copied int
+	var first, last uint64
+	_, err = f.each(dir, wal.RecoverFail, errOut, func(e *wal.Element) error {
+		if _, err := w.Append(e); err != nil {
+			return err
+		}
+
+		if copied == 0 {
+			first = e.Lsn
+		}
+		last = e.Lsn
+		copied++
+
+		return nil
+	})
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if f.json {
+		return printJSON(out, struct {
+			Out      string `json:"out"`
+			Records  int    `json:"records"`
+			FirstLSN uint64 `json:"first_lsn"`
+			LastLSN  uint64 `json:"last_lsn"`
+		}{*outDir, copied, first, last})
+	}
+
+	fmt.Fprintf(out, "copied %d records (lsn %d-%d) to %s\n", copied, first, last, *outDir)
+
+	return nil
+}
+
+func decodeCommand(e *wal.Element) (*wire.Command, error) {
+	cmd := &wire.Command{}
+	if err := proto.Unmarshal(e.Payload, cmd); err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+// commandText renders cmd the way it would be typed, quoting arguments that
+// would not survive a split on spaces.
+func commandText(cmd *wire.Command) string {
+	parts := []string{cmd.Cmd}
+	for _, arg := range cmd.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func printJSON(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
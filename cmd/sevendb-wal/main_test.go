@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sevenDatabase/SevenDB-go/wal"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+// writeWAL appends one record per command to a new WAL in dir and returns the
+// path of its only segment.
+func writeWAL(t *testing.T, dir string, cmds ...*wire.Command) string {
+	t.Helper()
+
+	w, err := wal.OpenWriter(dir, wal.Options{SyncPolicy: wal.SyncNever})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	defer w.Close()
+
+	for _, cmd := range cmds {
+		payload, err := proto.Marshal(cmd)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if _, err := w.Append(&wal.Element{ElementType: wal.ElementType_ELEMENT_TYPE_COMMAND, Payload: payload}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.wal"))
+	if err != nil || len(paths) != 1 {
+		t.Fatalf("Glob() = %v, %v, want one segment", paths, err)
+	}
+
+	return paths[0]
+}
+
+// corruptFirstRecord flips a byte in the payload of the first record.
+func corruptFirstRecord(t *testing.T, path string) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	data[8] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func sampleCommands() []*wire.Command {
+	return []*wire.Command{
+		{Cmd: "SET", Args: []string{"k1", "v1"}},
+		{Cmd: "GET", Args: []string{"k1"}},
+		{Cmd: "SET", Args: []string{"k2", "two words"}},
+	}
+}
+
+func TestCatPrintsCommands(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	writeWAL(t, dir, sampleCommands()...)
+	var out, errOut bytes.Buffer
+
+	// act
+	err := runCat([]string{"-from", "2", dir}, &out, &errOut)
+
+	// assert
+	if err != nil {
+		t.Fatalf("runCat() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[1], `SET k2 "two words"`) {
+		t.Errorf("runCat() printed %q, want lsns 2 and 3", lines)
+	}
+}
+
+func TestCatAndStatsSkipDamagedRecords(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	corruptFirstRecord(t, writeWAL(t, dir, sampleCommands()...))
+	var catOut, catErr, statsOut, statsErr bytes.Buffer
+
+	// act
+	catRunErr := runCat([]string{dir}, &catOut, &catErr)
+	statsRunErr := runStats([]string{"-json", dir}, &statsOut, &statsErr)
+
+	// assert
+	if catRunErr != nil || statsRunErr != nil {
+		t.Fatalf("runCat() error = %v, runStats() error = %v", catRunErr, statsRunErr)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(catOut.String()), "\n"); len(lines) != 2 {
+		t.Errorf("runCat() printed %d records, want 2", len(lines))
+	}
+
+	if !strings.Contains(catErr.String(), "skipped damaged record") || !strings.Contains(statsErr.String(), "skipped damaged record") {
+		t.Errorf("skipped records reported as %q and %q, want a report from both", catErr.String(), statsErr.String())
+	}
+
+	var stats struct {
+		Records  int            `json:"records"`
+		Skipped  int            `json:"skipped"`
+		Commands map[string]int `json:"commands"`
+	}
+	if err := json.Unmarshal(statsOut.Bytes(), &stats); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if stats.Records != 2 || stats.Skipped != 1 || stats.Commands["SET"] != 1 || stats.Commands["GET"] != 1 {
+		t.Errorf("runStats() = %+v, want 2 records, 1 skipped, one SET and one GET", stats)
+	}
+}
+
+func TestVerifyReportsCorruption(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	corruptFirstRecord(t, writeWAL(t, dir, sampleCommands()...))
+	var out bytes.Buffer
+
+	// act
+	err := runVerify([]string{dir}, &out, io.Discard)
+
+	// assert
+	if !errors.Is(err, errCorrupt) {
+		t.Errorf("runVerify() error = %v, want errCorrupt", err)
+	}
+
+	if !strings.Contains(out.String(), "2 good records, 1 corrupt records") {
+		t.Errorf("runVerify() printed %q", out.String())
+	}
+}
+
+func TestCutCopiesRange(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	writeWAL(t, dir, sampleCommands()...)
+	outDir := filepath.Join(t.TempDir(), "cut")
+
+	// act
+	err := runCut([]string{"-from", "2", "-to", "2", "-out", outDir, dir}, io.Discard, io.Discard)
+
+	// assert
+	if err != nil {
+		t.Fatalf("runCut() error = %v", err)
+	}
+
+	r, err := wal.NewReader(outDir, 0)
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	defer r.Close()
+
+	var lsns []uint64
+	for {
+		e, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		lsns = append(lsns, e.Lsn)
+	}
+
+	if len(lsns) != 1 || lsns[0] != 2 {
+		t.Errorf("cut WAL holds lsns %v, want [2]", lsns)
+	}
+}
+
+func TestCutRequiresFrom(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	writeWAL(t, dir, sampleCommands()...)
+	outDir := filepath.Join(t.TempDir(), "cut")
+
+	// act
+	err := runCut([]string{"-out", outDir, dir}, io.Discard, io.Discard)
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "-from is required") {
+		t.Errorf("runCut() error = %v, want -from is required", err)
+	}
+
+	if _, err := os.Stat(outDir); !os.IsNotExist(err) {
+		t.Errorf("runCut() created %s without -from", outDir)
+	}
+}
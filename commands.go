@@ -0,0 +1,115 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"strings"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// commandSpec describes where the keys of a command sit in its args. Keys are
+// args[firstKey], args[firstKey+step], ... up to args[lastKey]; a negative
+// lastKey counts from the end. firstKey is -1 for commands without keys.
+// readOnly commands never modify the keyspace and may be served by replicas.
+type commandSpec struct {
+	firstKey int
+	lastKey  int
+	step     int
+	readOnly bool
+}
+
+var (
+	noKeys   = commandSpec{firstKey: -1}
+	firstArg = commandSpec{firstKey: 0, lastKey: 0, step: 1}
+	allArgs  = commandSpec{firstKey: 0, lastKey: -1, step: 1}
+)
+
+var commandTable = map[string]commandSpec{
+	"PING":      noKeys,
+	"ECHO":      noKeys,
+	"HANDSHAKE": noKeys,
+	"KEYS":      readOnly(noKeys),
+	"FLUSHDB":   noKeys,
+	"UNWATCH":   noKeys,
+
+	"DEL":    allArgs,
+	"EXISTS": readOnly(allArgs),
+
+	"TYPE":         readOnly(firstArg),
+	"GET":          readOnly(firstArg),
+	"SET":          firstArg,
+	"GETDEL":       firstArg,
+	"GETEX":        firstArg,
+	"GETSET":       firstArg,
+	"INCR":         firstArg,
+	"DECR":         firstArg,
+	"INCRBY":       firstArg,
+	"DECRBY":       firstArg,
+	"EXPIRE":       firstArg,
+	"EXPIREAT":     firstArg,
+	"EXPIRETIME":   readOnly(firstArg),
+	"TTL":          readOnly(firstArg),
+	"GETWATCH":     firstArg,
+	"HGET":         readOnly(firstArg),
+	"HSET":         firstArg,
+	"HGETALL":      readOnly(firstArg),
+	"HGETWATCH":    firstArg,
+	"HGETALLWATCH": firstArg,
+	"ZADD":         firstArg,
+	"ZCOUNT":       readOnly(firstArg),
+	"ZRANGE":       readOnly(firstArg),
+	"ZPOPMAX":      firstArg,
+	"ZREM":         firstArg,
+	"ZPOPMIN":      firstArg,
+	"ZRANK":        readOnly(firstArg),
+	"ZCARD":        readOnly(firstArg),
+	"ZRANGEWATCH":  firstArg,
+	"ZCOUNTWATCH":  firstArg,
+	"ZCARDWATCH":   firstArg,
+	"ZRANKWATCH":   firstArg,
+	"GEOADD":       firstArg,
+	"GEODIST":      readOnly(firstArg),
+	"GEOSEARCH":    readOnly(firstArg),
+	"GEOHASH":      readOnly(firstArg),
+	"GEOPOS":       readOnly(firstArg),
+}
+
+func readOnly(spec commandSpec) commandSpec {
+	spec.readOnly = true
+	return spec
+}
+
+func lookupCommand(cmd string) (commandSpec, bool) {
+	spec, ok := commandTable[strings.ToUpper(cmd)]
+	return spec, ok
+}
+
+// commandKeys returns the indexes into cmd.Args that hold keys.
+func commandKeys(cmd *wire.Command) []int {
+	spec, ok := lookupCommand(cmd.Cmd)
+	if !ok || spec.firstKey < 0 || spec.firstKey >= len(cmd.Args) {
+		return nil
+	}
+
+	last := spec.lastKey
+	if last < 0 {
+		last += len(cmd.Args)
+	}
+	if last >= len(cmd.Args) {
+		last = len(cmd.Args) - 1
+	}
+
+	var idxs []int
+	for i := spec.firstKey; i <= last; i += spec.step {
+		idxs = append(idxs, i)
+	}
+
+	return idxs
+}
+
+func isReadOnly(cmd *wire.Command) bool {
+	spec, ok := lookupCommand(cmd.Cmd)
+	return ok && spec.readOnly
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/sevenDatabase/SevenDB-go/internal"
+)
+
+// Compressor compresses frame payloads once both peers agreed on it during
+// the handshake.
+type Compressor = internal.Compressor
+
+// DefaultCompressionThreshold is the payload size below which frames are
+// sent uncompressed.
+const DefaultCompressionThreshold = internal.DefaultCompressionThreshold
+
+// NewFlateCompressor returns a raw DEFLATE compressor at the given
+// compress/flate level.
+func NewFlateCompressor(level int) (Compressor, error) {
+	return internal.NewFlateCompressor(level)
+}
+
+// NewGzipCompressor returns a gzip compressor at the given compress/gzip
+// level.
+func NewGzipCompressor(level int) (Compressor, error) {
+	return internal.NewGzipCompressor(level)
+}
+
+type compressionConfig struct {
+	compressors []Compressor
+	threshold   int
+}
+
+// WithCompression offers compressors to the server in order of preference.
+// Once the server accepts one, frames of at least threshold bytes are
+// compressed in both directions; servers that do not support compression
+// keep the connection uncompressed. A threshold of zero or less means
+// DefaultCompressionThreshold.
+func WithCompression(threshold int, compressors ...Compressor) option {
+	return func(c *Client) {
+		if len(compressors) == 0 {
+			c.optErr = errors.New("WithCompression needs at least one compressor")
+			return
+		}
+
+		if threshold <= 0 {
+			threshold = DefaultCompressionThreshold
+		}
+
+		c.compression = compressionConfig{compressors: compressors, threshold: threshold}
+	}
+}
+
+// offer returns the handshake option listing the compressors, if any.
+func (cc compressionConfig) offer() []string {
+	if len(cc.compressors) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(cc.compressors))
+	for i, compressor := range cc.compressors {
+		names[i] = compressor.Name()
+	}
+
+	return []string{internal.HandshakeOption(internal.CompressionOption, strings.Join(names, ","))}
+}
+
+// accepted returns the compressor the server picked in its handshake reply.
+func (cc compressionConfig) accepted(options map[string]string) Compressor {
+	return pickCompressor(options[internal.CompressionOption], cc.compressors)
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// Endpoint is a server the client may connect to. Lower priorities are
+// preferred when hosts are selected by priority.
+type Endpoint struct {
+	Host     string
+	Port     int
+	Priority int
+}
+
+func (e Endpoint) String() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+}
+
+type HostSelection int
+
+const (
+	SelectByPriority HostSelection = iota
+	SelectRandom
+)
+
+type endpointState struct {
+	Endpoint
+	healthy bool
+}
+
+type endpoints struct {
+	mu        sync.Mutex
+	list      []*endpointState
+	active    int
+	selection HostSelection
+}
+
+// WithSeeds adds standby servers given as "host:port" addresses. They are
+// tried after the host passed to NewClient, in the order given.
+func WithSeeds(addrs ...string) option {
+	return func(c *Client) {
+		for _, addr := range addrs {
+			host, portStr, err := net.SplitHostPort(addr)
+			if err != nil {
+				c.optErr = fmt.Errorf("invalid seed address %q: %w", addr, err)
+				return
+			}
+
+			port, err := strconv.Atoi(portStr)
+			if err != nil {
+				c.optErr = fmt.Errorf("invalid seed address %q: %w", addr, err)
+				return
+			}
+
+			c.endpoints.add(Endpoint{Host: host, Port: port, Priority: len(c.endpoints.list)})
+		}
+	}
+}
+
+// WithEndpoints adds standby servers with explicit priorities.
+func WithEndpoints(eps ...Endpoint) option {
+	return func(c *Client) {
+		for _, ep := range eps {
+			c.endpoints.add(ep)
+		}
+	}
+}
+
+// WithHostSelection sets the order in which healthy endpoints are tried,
+// by priority by default.
+func WithHostSelection(selection HostSelection) option {
+	return func(c *Client) {
+		c.endpoints.selection = selection
+	}
+}
+
+// WithHealthCheck probes every endpoint with PING at the given interval so
+// failover skips hosts that are known to be down.
+func WithHealthCheck(interval time.Duration) option {
+	return func(c *Client) {
+		c.healthCheckInterval = interval
+	}
+}
+
+// ActiveEndpoint reports the server the main connection is currently using.
+func (c *Client) ActiveEndpoint() Endpoint {
+	c.endpoints.mu.Lock()
+	defer c.endpoints.mu.Unlock()
+
+	return c.endpoints.list[c.endpoints.active].Endpoint
+}
+
+func (e *endpoints) add(ep Endpoint) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.list = append(e.list, &endpointState{Endpoint: ep, healthy: true})
+}
+
+// candidates lists endpoint indexes in the order they should be tried. The
+// endpoint failed, unless it is -1, is demoted behind the other healthy
+// ones; unhealthy endpoints are always tried last.
+func (e *endpoints) candidates(failed int) []int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var healthy, unhealthy []int
+	for i, ep := range e.list {
+		if i == failed {
+			continue
+		}
+
+		if ep.healthy {
+			healthy = append(healthy, i)
+		} else {
+			unhealthy = append(unhealthy, i)
+		}
+	}
+
+	e.order(healthy)
+	e.order(unhealthy)
+
+	if failed >= 0 {
+		healthy = append(healthy, failed)
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+func (e *endpoints) order(idxs []int) {
+	switch e.selection {
+	case SelectRandom:
+		rand.Shuffle(len(idxs), func(i, j int) { idxs[i], idxs[j] = idxs[j], idxs[i] })
+	default:
+		sort.SliceStable(idxs, func(i, j int) bool {
+			return e.list[idxs[i]].Priority < e.list[idxs[j]].Priority
+		})
+	}
+}
+
+func (e *endpoints) get(i int) Endpoint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.list[i].Endpoint
+}
+
+func (e *endpoints) setHealthy(i int, healthy bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.list[i].healthy = healthy
+}
+
+func (e *endpoints) activate(i int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.active = i
+}
+
+func (e *endpoints) len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return len(e.list)
+}
+
+// dialEndpoints connects to the first reachable endpoint, trying the one
+// failed last.
+func (c *Client) dialEndpoints(failed int) (*ClientWire, *wire.WireError) {
+	var lastErr *wire.WireError
+	for _, i := range c.endpoints.candidates(failed) {
+		ep := c.endpoints.get(i)
+
+		clientWire, err := NewClientWireWithDialer(c.maxResponseSize, ep.Host, ep.Port, c.dial)
+		if err != nil {
+			slog.Warn("failed to connect to endpoint", "endpoint", ep, "error", err)
+			c.endpoints.setHealthy(i, false)
+			lastErr = err
+			continue
+		}
+
+		clientWire.SetFrameTimeout(c.frameTimeout)
+		clientWire.endpoint = i
+		c.endpoints.setHealthy(i, true)
+		return clientWire, nil
+	}
+
+	return nil, lastErr
+}
+
+func (c *Client) healthCheck() {
+	ticker := time.NewTicker(c.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			for i := 0; i < c.endpoints.len(); i++ {
+				c.endpoints.setHealthy(i, c.probe(c.endpoints.get(i)))
+			}
+		}
+	}
+}
+
+func (c *Client) probe(ep Endpoint) bool {
+	clientWire, err := NewClientWireWithDialer(c.maxResponseSize, ep.Host, ep.Port, c.dial)
+	if err != nil {
+		return false
+	}
+	defer clientWire.Close()
+
+	if err := clientWire.Send(&wire.Command{Cmd: "PING"}); err != nil {
+		return false
+	}
+
+	resp, err := clientWire.Receive()
+	return err == nil && resp.Status == wire.Status_OK
+}
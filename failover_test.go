@@ -0,0 +1,170 @@
+package dicedb_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/sevendbmock"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func pong() *wire.Result {
+	return &wire.Result{
+		Status:   wire.Status_OK,
+		Response: &wire.Result_PINGRes{PINGRes: &wire.PINGRes{Message: "PONG"}},
+	}
+}
+
+func TestConnectsToFirstReachableSeed(t *testing.T) {
+	// arrange
+	down, err := sevendbmock.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	down.Close()
+
+	standby, err := sevendbmock.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer standby.Close()
+
+	// act
+	client, err := dicedb.NewClient(down.Host(), down.Port(), dicedb.WithSeeds(standby.Addr()))
+
+	// assert
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	if got := client.ActiveEndpoint().String(); got != standby.Addr() {
+		t.Errorf("ActiveEndpoint() = %s, want %s", got, standby.Addr())
+	}
+}
+
+func TestFailsOverWhenPrimaryGoesAway(t *testing.T) {
+	// arrange
+	primary, err := sevendbmock.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	primary.Expect("PING").Return(pong())
+
+	standby, err := sevendbmock.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer standby.Close()
+	standby.Expect("PING").Times(3).Return(pong())
+
+	client, err := dicedb.NewClient(primary.Host(), primary.Port(), dicedb.WithSeeds(standby.Addr()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	if resp := client.Fire(&wire.Command{Cmd: "PING"}); resp.Status != wire.Status_OK {
+		t.Fatalf("Fire() on primary = %v, want OK", resp)
+	}
+
+	// act
+	primary.Close()
+
+	var resp *wire.Result
+	for attempt := 0; attempt < 3; attempt++ {
+		if resp = client.Fire(&wire.Command{Cmd: "PING"}); resp.Status == wire.Status_OK {
+			break
+		}
+	}
+
+	// assert
+	if resp.GetPINGRes().GetMessage() != "PONG" {
+		t.Errorf("Fire() after failover = %v, want PONG", resp)
+	}
+
+	if got := client.ActiveEndpoint().String(); got != standby.Addr() {
+		t.Errorf("ActiveEndpoint() = %s, want %s", got, standby.Addr())
+	}
+}
+
+func TestInvalidSeed(t *testing.T) {
+	// act
+	client, err := dicedb.NewClient("localhost", 7379, dicedb.WithSeeds("no-port"))
+
+	// assert
+	if client != nil || err == nil {
+		t.Errorf("NewClient() = %v, %v, want invalid seed error", client, err)
+	}
+}
+
+func TestActiveEndpointFollowsTheMainConnection(t *testing.T) {
+	// arrange
+	primary, err := sevendbmock.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer primary.Close()
+
+	standby, err := sevendbmock.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer standby.Close()
+
+	// Only the first connection to the primary gets through, so the watch
+	// connection lands on the standby.
+	var primaryDials atomic.Int64
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == primary.Addr() && primaryDials.Add(1) > 1 {
+			return nil, errors.New("primary refuses connections")
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+
+	client, err := dicedb.NewClient(primary.Host(), primary.Port(), dicedb.WithSeeds(standby.Addr()), dicedb.WithDialer(dialer))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// act
+	_, err = client.WatchCh()
+
+	// assert
+	if err != nil {
+		t.Fatalf("WatchCh() error = %v", err)
+	}
+
+	if got := client.ActiveEndpoint().String(); got != primary.Addr() {
+		t.Errorf("ActiveEndpoint() = %s, want the main connection's %s", got, primary.Addr())
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	// arrange
+	srv, err := sevendbmock.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer srv.Close()
+
+	client, err := dicedb.NewClient(srv.Host(), srv.Port())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	// act
+	client.Close()
+	client.Close()
+
+	// assert
+	if res := client.Fire(&wire.Command{Cmd: "PING"}); res.Status != wire.Status_ERR {
+		t.Errorf("Fire() after Close = %v, want an error", res)
+	}
+}
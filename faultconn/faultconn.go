@@ -0,0 +1,222 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+// Package faultconn wraps a net.Conn and injects network faults for chaos
+// testing the wire. Every random decision is drawn from a seeded source, so
+// a failing run can be reproduced by reusing its seed.
+package faultconn
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// Config selects which faults are injected. Rates are probabilities in
+// [0, 1] evaluated independently on every Read or Write call.
+type Config struct {
+	Seed int64
+
+	// Latency is added before every Read and Write, plus a random amount up
+	// to Jitter.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// Bandwidth caps throughput in bytes per second in each direction. Zero
+	// means unlimited.
+	Bandwidth int
+
+	// ShortWriteRate makes a Write deliver only part of the buffer and
+	// return io.ErrShortWrite.
+	ShortWriteRate float64
+
+	// ReadTimeoutRate makes a Read fail with a timeout net.OpError before
+	// consuming any data.
+	ReadTimeoutRate float64
+
+	// DisconnectRate closes the underlying connection and fails the call as
+	// if the peer had gone away.
+	DisconnectRate float64
+
+	// CorruptRate flips one random bit in the data of a Read or Write.
+	CorruptRate float64
+}
+
+type Conn struct {
+	net.Conn
+	cfg Config
+	// reads and writes draw from sources of their own, so a reader and a
+	// writer goroutine see the same faults however they are scheduled.
+	reads  *source
+	writes *source
+}
+
+func Wrap(conn net.Conn, cfg Config) *Conn {
+	seeds := rand.New(rand.NewSource(cfg.Seed)) //nolint:gosec
+
+	return &Conn{
+		Conn:   conn,
+		cfg:    cfg,
+		reads:  newSource(seeds.Int63()),
+		writes: newSource(seeds.Int63()),
+	}
+}
+
+// NetConn returns the connection c wraps.
+func (c *Conn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// Dialer returns a dial function that wraps every connection opened by base.
+// The n-th connection is seeded with cfg.Seed+n so a sequence of reconnects
+// stays reproducible.
+func Dialer(base func(ctx context.Context, network, addr string) (net.Conn, error), cfg Config) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	var mu sync.Mutex
+	var dialed int64
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := base(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		connCfg := cfg
+		connCfg.Seed = cfg.Seed + dialed
+		dialed++
+		mu.Unlock()
+
+		return Wrap(conn, connCfg), nil
+	}
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	c.delay(c.reads)
+
+	if c.reads.chance(c.cfg.ReadTimeoutRate) {
+		return 0, c.opError("read", timeoutError{})
+	}
+
+	if c.reads.chance(c.cfg.DisconnectRate) {
+		c.Conn.Close()
+		return 0, c.opError("read", net.ErrClosed)
+	}
+
+	if len(p) > 0 && c.cfg.Bandwidth > 0 && len(p) > c.cfg.Bandwidth {
+		p = p[:c.cfg.Bandwidth]
+	}
+
+	n, err := c.Conn.Read(p)
+	if n > 0 && c.reads.chance(c.cfg.CorruptRate) {
+		c.reads.flipBit(p[:n])
+	}
+	c.throttle(n)
+
+	return n, err
+}
+
+func (c *Conn) Write(p []byte) (int, error) {
+	c.delay(c.writes)
+
+	if c.writes.chance(c.cfg.DisconnectRate) {
+		c.Conn.Close()
+		return 0, c.opError("write", net.ErrClosed)
+	}
+
+	buffer := p
+	short := len(p) > 1 && c.writes.chance(c.cfg.ShortWriteRate)
+	if short {
+		buffer = buffer[:1+c.writes.intn(len(p)-1)]
+	}
+
+	if len(buffer) > 0 && c.writes.chance(c.cfg.CorruptRate) {
+		buffer = append([]byte(nil), buffer...)
+		c.writes.flipBit(buffer)
+	}
+
+	n, err := c.Conn.Write(buffer)
+	c.throttle(n)
+	if err == nil && short {
+		err = io.ErrShortWrite
+	}
+
+	return n, err
+}
+
+func (c *Conn) delay(s *source) {
+	d := c.cfg.Latency
+	if c.cfg.Jitter > 0 {
+		d += time.Duration(s.int63n(int64(c.cfg.Jitter)))
+	}
+
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+func (c *Conn) throttle(n int) {
+	if c.cfg.Bandwidth <= 0 || n <= 0 {
+		return
+	}
+
+	time.Sleep(time.Duration(n) * time.Second / time.Duration(c.cfg.Bandwidth))
+}
+
+func (c *Conn) opError(op string, err error) error {
+	return &net.OpError{
+		Op:     op,
+		Net:    c.LocalAddr().Network(),
+		Source: c.LocalAddr(),
+		Addr:   c.RemoteAddr(),
+		Err:    err,
+	}
+}
+
+// source is a seeded random source safe for concurrent use.
+type source struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newSource(seed int64) *source {
+	return &source{rng: rand.New(rand.NewSource(seed))} //nolint:gosec
+}
+
+func (s *source) flipBit(buffer []byte) {
+	bit := s.intn(len(buffer) * 8)
+	buffer[bit/8] ^= 1 << (bit % 8)
+}
+
+func (s *source) chance(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rng.Float64() < rate
+}
+
+func (s *source) intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rng.Intn(n)
+}
+
+func (s *source) int63n(n int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rng.Int63n(n)
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "faultconn: injected timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,245 @@
+package faultconn
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/internal"
+	"github.com/sevenDatabase/SevenDB-go/sevendbmock"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func loopback(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- conn
+	}()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+
+	server, ok := <-accepted
+	if !ok {
+		t.Fatalf("Accept() failed")
+	}
+
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	return client, server
+}
+
+func TestCorruptionIsReproducible(t *testing.T) {
+	// arrange
+	msg := bytes.Repeat([]byte{0xAA}, 64)
+	cfg := Config{Seed: 42, CorruptRate: 1}
+	received := make([][]byte, 2)
+
+	for i := range received {
+		client, server := loopback(t)
+		conn := Wrap(client, cfg)
+
+		// act
+		if _, err := conn.Write(msg); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+
+		received[i] = make([]byte, len(msg))
+		if _, err := io.ReadFull(server, received[i]); err != nil {
+			t.Fatalf("ReadFull() error = %v", err)
+		}
+	}
+
+	// assert
+	if bytes.Equal(received[0], msg) {
+		t.Errorf("Write() did not corrupt the payload")
+	}
+
+	if !bytes.Equal(received[0], received[1]) {
+		t.Errorf("corruption differs between runs with the same seed: %x vs %x", received[0], received[1])
+	}
+}
+
+func TestShortWrite(t *testing.T) {
+	// arrange
+	client, _ := loopback(t)
+	conn := Wrap(client, Config{Seed: 1, ShortWriteRate: 1})
+
+	// act
+	n, err := conn.Write(make([]byte, 32))
+
+	// assert
+	if !errors.Is(err, io.ErrShortWrite) || n >= 32 {
+		t.Errorf("Write() = %d, %v, want short write", n, err)
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	// arrange
+	client, _ := loopback(t)
+	conn := Wrap(client, Config{Seed: 1, ReadTimeoutRate: 1})
+
+	// act
+	_, err := conn.Read(make([]byte, 8))
+
+	// assert
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || !opErr.Timeout() {
+		t.Errorf("Read() error = %v, want timeout net.OpError", err)
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	// arrange
+	client, _ := loopback(t)
+	conn := Wrap(client, Config{Seed: 1, DisconnectRate: 1})
+
+	// act
+	_, err := conn.Write([]byte("ping"))
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "use of closed network connection") {
+		t.Errorf("Write() error = %v, want closed connection", err)
+	}
+}
+
+func TestConcurrentFaultsAreReproducible(t *testing.T) {
+	// arrange
+	const calls = 200
+	cfg := Config{Seed: 7, Jitter: 50 * time.Microsecond, ReadTimeoutRate: 0.3, ShortWriteRate: 0.3}
+
+	run := func() (reads, writes []bool) {
+		client, server := loopback(t)
+		conn := Wrap(client, cfg)
+		go io.Copy(io.Discard, server) //nolint:errcheck
+		if _, err := server.Write(make([]byte, calls)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+
+		reads, writes = make([]bool, calls), make([]bool, calls)
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range reads {
+				_, err := conn.Read(make([]byte, 1))
+				var opErr *net.OpError
+				reads[i] = errors.As(err, &opErr) && opErr.Timeout()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := range writes {
+				_, err := conn.Write(make([]byte, 8))
+				writes[i] = errors.Is(err, io.ErrShortWrite)
+			}
+		}()
+		wg.Wait()
+
+		return reads, writes
+	}
+
+	// act
+	reads0, writes0 := run()
+	reads1, writes1 := run()
+
+	// assert
+	if !slices.Contains(reads0, true) || !slices.Contains(writes0, true) {
+		t.Fatalf("no faults were injected")
+	}
+
+	if !slices.Equal(reads0, reads1) {
+		t.Errorf("read faults differ between runs with the same seed")
+	}
+
+	if !slices.Equal(writes0, writes1) {
+		t.Errorf("write faults differ between runs with the same seed")
+	}
+}
+
+func TestTCPWireSurvivesRetryableFaults(t *testing.T) {
+	// arrange
+	client, server := loopback(t)
+	sender := internal.NewTCPWire(1024, Wrap(client, Config{Seed: 7, ShortWriteRate: 0.3}))
+	receiver := internal.NewTCPWire(1024, Wrap(server, Config{Seed: 7, ReadTimeoutRate: 0.2}))
+	msgs := [][]byte{[]byte("first message"), bytes.Repeat([]byte{'x'}, 512), []byte("last")}
+
+	for _, want := range msgs {
+		// act
+		if err := sender.Send(want); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		got, err := receiver.Receive()
+
+		// assert
+		if err != nil {
+			t.Fatalf("Receive() error = %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Receive() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestDialerWrapsClientWire(t *testing.T) {
+	// arrange
+	srv, err := sevendbmock.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer srv.Close()
+	srv.Expect("PING").Return(&wire.Result{
+		Status:   wire.Status_OK,
+		Response: &wire.Result_PINGRes{PINGRes: &wire.PINGRes{Message: "PONG"}},
+	})
+
+	var d net.Dialer
+	latency := 20 * time.Millisecond
+	dial := Dialer(d.DialContext, Config{Seed: 3, Latency: latency})
+
+	cw, werr := dicedb.NewClientWireWithDialer(1024, srv.Host(), srv.Port(), dial)
+	if werr != nil {
+		t.Fatalf("NewClientWireWithDialer() error = %v", werr)
+	}
+	defer cw.Close()
+
+	// act
+	start := time.Now()
+	if werr := cw.Send(&wire.Command{Cmd: "PING"}); werr != nil {
+		t.Fatalf("Send() error = %v", werr)
+	}
+	resp, werr := cw.Receive()
+
+	// assert
+	if werr != nil || resp.GetPINGRes().GetMessage() != "PONG" {
+		t.Fatalf("Receive() = %v, %v, want PONG", resp, werr)
+	}
+	if elapsed := time.Since(start); elapsed < 2*latency {
+		t.Errorf("round trip took %v, want at least %v", elapsed, 2*latency)
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// Future is the result of a command sent with FireAsync, which arrives
+// later.
+type Future struct {
+	// ctx is what the command was issued with, passed on to the pipeline
+	// hooks.
+	ctx       context.Context
+	cmd       *wire.Command
+	done      chan struct{}
+	completed atomic.Bool
+
+	mu  sync.Mutex
+	res *wire.Result
+	// err is the connection failure behind an error result, nil when the
+	// server sent the result.
+	err *wire.WireError
+	// stop unregisters the context the future is bound to.
+	stop func() bool
+}
+
+func newFuture(ctx context.Context, cmd *wire.Command) *Future {
+	return &Future{
+		ctx:  ctx,
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+}
+
+// Done is closed once the result arrived.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the result arrived and returns it. Failures to deliver
+// the command are reported as wire.Status_ERR results, like Fire does.
+func (f *Future) Wait() *wire.Result {
+	<-f.done
+	return f.res
+}
+
+// Result returns the result, or nil while it has not arrived.
+func (f *Future) Result() *wire.Result {
+	select {
+	case <-f.done:
+		return f.res
+	default:
+		return nil
+	}
+}
+
+// bind completes f with an error once ctx ends before the result arrived.
+func (f *Future) bind(ctx context.Context) {
+	if ctx.Done() == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.stop = context.AfterFunc(ctx, func() {
+		f.complete(abandoned(ctx.Err()))
+	})
+}
+
+// wait is Wait also returning the connection failure behind the result.
+func (f *Future) wait() (*wire.Result, *wire.WireError) {
+	<-f.done
+	return f.res, f.err
+}
+
+// complete sets the result unless f already has one and reports whether it
+// did.
+func (f *Future) complete(res *wire.Result) bool {
+	return f.settle(res, nil)
+}
+
+// fail completes f with res, the result reporting the connection failure
+// err.
+func (f *Future) fail(err *wire.WireError, res *wire.Result) bool {
+	return f.settle(res, err)
+}
+
+func (f *Future) settle(res *wire.Result, err *wire.WireError) bool {
+	if !f.completed.CompareAndSwap(false, true) {
+		return false
+	}
+
+	f.mu.Lock()
+	f.res, f.err = res, err
+	stop := f.stop
+	f.mu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+	close(f.done)
+
+	return true
+}
+
+func abandoned(err error) *wire.Result {
+	return &wire.Result{
+		Status:  wire.Status_ERR,
+		Message: fmt.Sprintf("stopped waiting for the result: %s", err),
+	}
+}
+
+// TypedFuture is a Future whose result is decoded into a T.
+type TypedFuture[T any] struct {
+	*Future
+	decode func(*wire.Result) T
+}
+
+// Wait blocks until the result arrived and returns it decoded, or the
+// error the server or the connection reported.
+func (f *TypedFuture[T]) Wait() (T, error) {
+	res := f.Future.Wait()
+	if res.Status == wire.Status_ERR {
+		var zero T
+		return zero, errors.New(res.Message)
+	}
+
+	return f.decode(res), nil
+}
+
+// FireAsync sends cmd and returns at once with a Future for its result, so
+// many commands can be in flight without a goroutine each. On a multiplexed
+// connection they share it with Fire. Otherwise they share a connection of
+// their own, on which a writer goroutine sends them in order and a reader
+// goroutine hands out the results in the same order. Once ctx ends the
+// future completes with an error, though the server may still execute the
+// command.
+//
+// Ordering against Fire is not kept. Without multiplexing, async commands
+// travel on that connection of their own, so a Fire issued while some are
+// pending can execute before them even when it touches the same key, unless
+// auto-pipelining routes Fire through the same connection. On a multiplexed
+// connection the server may execute commands in flight together in any
+// order. Wait for the futures first when the order matters.
+//
+// With process hooks added, they run on the caller's goroutine before the
+// command is queued, so commands keep the order they were sent in. The
+// hooks see a QUEUED result in place of the server's answer, which the
+// future carries. A hook that returns a result of its own, whether or not
+// it passed the command on, completes the future with it.
+func (c *Client) FireAsync(ctx context.Context, cmd *wire.Command) *Future {
+	chain := c.hooks.Load()
+	if len(chain.hooks) == 0 {
+		return c.sendAsync(ctx, cmd)
+	}
+
+	call := &asyncCall{}
+	res := chain.async(context.WithValue(ctx, asyncCallKey{}, call), cmd)
+	if call.sent != nil && res == call.queued {
+		return call.sent
+	}
+
+	f := newFuture(ctx, cmd)
+	f.complete(res)
+
+	return f
+}
+
+// asyncCall carries a FireAsync through the process hooks to processAsync.
+type asyncCall struct {
+	// sent is the future of the command the hooks passed on, and queued the
+	// result the hooks saw for it.
+	sent   *Future
+	queued *wire.Result
+}
+
+type asyncCallKey struct{}
+
+// sendAsync sends cmd without running the process hooks.
+func (c *Client) sendAsync(ctx context.Context, cmd *wire.Command) *Future {
+	if len(c.replicas.replicas) > 0 && isReadOnly(cmd) {
+		if r := c.replicas.pick(); r != nil {
+			if client := r.connect(time.Now()); client != nil {
+				return client.sendAsync(ctx, cmd)
+			}
+		}
+	}
+
+	f := newFuture(ctx, cmd)
+	if err := ctx.Err(); err != nil {
+		f.complete(abandoned(err))
+		return f
+	}
+	f.bind(ctx)
+
+	c.wireMu.Lock()
+	caps, m := c.mainWire.caps, c.mainWire.mux
+	c.wireMu.Unlock()
+
+	if m == nil {
+		c.sendPipelined(f)
+		return f
+	}
+
+	if res := oversized(cmd, caps); res != nil {
+		f.complete(res)
+		return f
+	}
+
+	switch err := c.sendMultiplexed(f, c.mainWire, m, c.restoreMainWire); {
+	case err == errNotMultiplexed:
+		c.sendPipelined(f)
+	case err != nil:
+		f.fail(err, sendFailure(err))
+	}
+
+	return f
+}
+
+// GetAsync is GET sent with FireAsync.
+func (c *Client) GetAsync(ctx context.Context, key string) *TypedFuture[string] {
+	return &TypedFuture[string]{
+		Future: c.FireAsync(ctx, &wire.Command{Cmd: "GET", Args: []string{key}}),
+		decode: func(res *wire.Result) string { return res.GetGETRes().GetValue() },
+	}
+}
+
+// ExistsAsync is EXISTS sent with FireAsync.
+func (c *Client) ExistsAsync(ctx context.Context, keys ...string) *TypedFuture[int64] {
+	return &TypedFuture[int64]{
+		Future: c.FireAsync(ctx, &wire.Command{Cmd: "EXISTS", Args: keys}),
+		decode: func(res *wire.Result) int64 { return res.GetEXISTSRes().GetCount() },
+	}
+}
+
+// IncrAsync is INCR sent with FireAsync.
+func (c *Client) IncrAsync(ctx context.Context, key string) *TypedFuture[int64] {
+	return &TypedFuture[int64]{
+		Future: c.FireAsync(ctx, &wire.Command{Cmd: "INCR", Args: []string{key}}),
+		decode: func(res *wire.Result) int64 { return res.GetINCRRes().GetValue() },
+	}
+}
+
+// TTLAsync is TTL sent with FireAsync.
+func (c *Client) TTLAsync(ctx context.Context, key string) *TypedFuture[int64] {
+	return &TypedFuture[int64]{
+		Future: c.FireAsync(ctx, &wire.Command{Cmd: "TTL", Args: []string{key}}),
+		decode: func(res *wire.Result) int64 { return res.GetTTLRes().GetSeconds() },
+	}
+}
@@ -0,0 +1,219 @@
+package dicedb_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/server"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func TestFireAsyncMatchesResultsToCommands(t *testing.T) {
+	tests := []struct {
+		name      string
+		multiplex bool
+	}{
+		{name: "pipelined"},
+		{name: "multiplexed", multiplex: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			var counter atomic.Int64
+			router := server.NewRouter()
+			router.HandleFunc("ECHO", echo)
+			router.HandleFunc("INCR", func(ctx context.Context, cmd *wire.Command) *wire.Result {
+				return &wire.Result{
+					Status:   wire.Status_OK,
+					Response: &wire.Result_INCRRes{INCRRes: &wire.INCRRes{Value: counter.Add(1)}},
+				}
+			})
+			_, addr := startServer(t, router)
+
+			newClient := func() (*dicedb.Client, error) {
+				if tt.multiplex {
+					return dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithRequestIDs())
+				}
+				return dicedb.NewClient(addr.IP.String(), addr.Port)
+			}
+			client, err := newClient()
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			defer client.Close()
+
+			// act
+			ctx := context.Background()
+			futures := make([]*dicedb.Future, 50)
+			for i := range futures {
+				futures[i] = client.FireAsync(ctx, &wire.Command{Cmd: "ECHO", Args: []string{strconv.Itoa(i)}})
+			}
+			incr := client.IncrAsync(ctx, "counter")
+
+			// assert
+			for i, f := range futures {
+				if got := f.Wait().GetECHORes().GetMessage(); got != strconv.Itoa(i) {
+					t.Errorf("future %d got %q", i, got)
+				}
+				if f.Result() == nil {
+					t.Errorf("future %d has no result after Wait", i)
+				}
+			}
+
+			if n, err := incr.Wait(); err != nil || n != 1 {
+				t.Errorf("IncrAsync().Wait() = %d, %v, want 1", n, err)
+			}
+		})
+	}
+}
+
+// fireAsyncUntilOK sends cmd with FireAsync up to three times, since a
+// command the lost connection may have carried is failed rather than resent.
+func fireAsyncUntilOK(client *dicedb.Client, cmd *wire.Command) *wire.Result {
+	var res *wire.Result
+	for attempt := 0; attempt < 3; attempt++ {
+		if res = client.FireAsync(context.Background(), cmd).Wait(); res.Status == wire.Status_OK {
+			break
+		}
+	}
+
+	return res
+}
+
+func TestFireAsyncConnectionIsRestored(t *testing.T) {
+	tests := []struct {
+		name      string
+		multiplex bool
+	}{
+		{name: "pipelined"},
+		{name: "multiplexed", multiplex: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			_, addr := startServer(t, server.HandlerFunc(echo))
+
+			tracker := &connTracker{}
+			newClient := func() (*dicedb.Client, error) {
+				if tt.multiplex {
+					return dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithDialer(tracker.dial), dicedb.WithRequestIDs())
+				}
+				return dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithDialer(tracker.dial))
+			}
+			client, err := newClient()
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			defer client.Close()
+
+			if res := client.FireAsync(context.Background(), &wire.Command{Cmd: "ECHO", Args: []string{"before"}}).Wait(); res.Status != wire.Status_OK {
+				t.Fatalf("FireAsync() = %v, want OK", res)
+			}
+
+			// act
+			tracker.dropAll()
+			res := fireAsyncUntilOK(client, &wire.Command{Cmd: "ECHO", Args: []string{"after"}})
+
+			// assert
+			if got := res.GetECHORes().GetMessage(); got != "after" {
+				t.Errorf("FireAsync() after the connection dropped = %v, want after", res)
+			}
+		})
+	}
+}
+
+func TestFireAsyncFailsOver(t *testing.T) {
+	tests := []struct {
+		name      string
+		multiplex bool
+	}{
+		{name: "pipelined"},
+		{name: "multiplexed", multiplex: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			primary, primaryAddr := startServer(t, answeredBy("primary"))
+			_, standbyAddr := startServer(t, answeredBy("standby"))
+
+			newClient := func() (*dicedb.Client, error) {
+				if tt.multiplex {
+					return dicedb.NewClient(primaryAddr.IP.String(), primaryAddr.Port, dicedb.WithSeeds(standbyAddr.String()), dicedb.WithRequestIDs())
+				}
+				return dicedb.NewClient(primaryAddr.IP.String(), primaryAddr.Port, dicedb.WithSeeds(standbyAddr.String()))
+			}
+			client, err := newClient()
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			defer client.Close()
+
+			if got := client.FireAsync(context.Background(), &wire.Command{Cmd: "ECHO"}).Wait().GetECHORes().GetMessage(); got != "primary" {
+				t.Fatalf("FireAsync() answered by %q, want primary", got)
+			}
+
+			// act
+			primary.Close()
+			res := fireAsyncUntilOK(client, &wire.Command{Cmd: "ECHO"})
+
+			// assert
+			if got := res.GetECHORes().GetMessage(); got != "standby" {
+				t.Errorf("FireAsync() after the primary went away = %v, want the standby's answer", res)
+			}
+		})
+	}
+}
+
+func TestFireAsyncWithHooksKeepsOrder(t *testing.T) {
+	// arrange
+	var mu sync.Mutex
+	var order []string
+	_, addr := startServer(t, server.HandlerFunc(func(ctx context.Context, cmd *wire.Command) *wire.Result {
+		mu.Lock()
+		order = append(order, cmd.Args[0])
+		mu.Unlock()
+		return echo(ctx, cmd)
+	}))
+
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	hook := &recordingHook{}
+	client.AddHook(hook)
+
+	// act
+	ctx := context.Background()
+	futures := make([]*dicedb.Future, 50)
+	for i := range futures {
+		futures[i] = client.FireAsync(ctx, &wire.Command{Cmd: "ECHO", Args: []string{strconv.Itoa(i)}})
+	}
+
+	// assert
+	for i, f := range futures {
+		if got := f.Wait().GetECHORes().GetMessage(); got != strconv.Itoa(i) {
+			t.Errorf("future %d got %q", i, got)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, got := range order {
+		if got != strconv.Itoa(i) {
+			t.Fatalf("server executed %v, want the order FireAsync was called in", order)
+		}
+	}
+
+	if n := hook.fired.Load(); n != int64(len(futures)) {
+		t.Errorf("process hook ran %d times, want %d", n, len(futures))
+	}
+}
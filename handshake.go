@@ -0,0 +1,262 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sevenDatabase/SevenDB-go/internal"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// ProtocolVersion is the newest protocol version this package speaks.
+// Version 1 is the original [id, mode] handshake without capabilities.
+const ProtocolVersion = 2
+
+// Capabilities is what the two ends of a connection agreed on during the
+// handshake. The zero value of each field means the feature is off.
+type Capabilities struct {
+	Version int
+	// Compression names the compressor in use.
+	Compression string
+	// MaxFrameSize is the largest frame the peer accepts.
+	MaxFrameSize int
+	// Checksums reports whether frames carry a CRC32C trailer.
+	Checksums bool
+	// Streaming reports whether large results are streamed in chunks.
+	Streaming bool
+	// RequestIDs reports whether commands and results carry request IDs, so
+	// several commands can be in flight and answered out of order.
+	RequestIDs bool
+}
+
+// ServerCapabilities is what a server offers in the handshake.
+type ServerCapabilities struct {
+	Compressors []Compressor
+	// CompressionThreshold is the result size below which results are sent
+	// uncompressed. Zero means DefaultCompressionThreshold.
+	CompressionThreshold int
+	// MaxFrameSize is the largest command the server accepts.
+	MaxFrameSize int
+	// Checksums accepts clients offering CRC32C frame trailers.
+	Checksums bool
+	// StreamChunkSize streams results larger than it, in chunks of that
+	// size, to clients that can read streams. Zero never streams.
+	StreamChunkSize int
+	// RequestIDs accepts clients offering request IDs.
+	RequestIDs bool
+}
+
+// AcceptHandshake negotiates the options a client sent after the [id, mode]
+// arguments of HANDSHAKE. It returns the agreed capabilities and the words to
+// add to the handshake result's message so the client learns them. Features
+// that change framing take effect after the next Send, so the handshake
+// result itself goes out the way the client expects it.
+func (sw *ServerWire) AcceptHandshake(options []string, offer ServerCapabilities) (Capabilities, []string) {
+	parsed := internal.ParseHandshakeOptions(options)
+	caps := Capabilities{Version: 1}
+	var ack []string
+
+	if v, err := strconv.Atoi(parsed[internal.VersionOption]); err == nil && v > 1 {
+		caps.Version = min(v, ProtocolVersion)
+		ack = append(ack, internal.HandshakeOption(internal.VersionOption, strconv.Itoa(caps.Version)))
+
+		if offer.MaxFrameSize > 0 {
+			ack = append(ack, internal.HandshakeOption(internal.MaxFrameOption, strconv.Itoa(offer.MaxFrameSize)))
+		}
+	}
+
+	if n, err := strconv.Atoi(parsed[internal.MaxFrameOption]); err == nil && n > 0 {
+		caps.MaxFrameSize = n
+	}
+
+	framing := &pendingFraming{}
+	if compressor := pickCompressor(parsed[internal.CompressionOption], offer.Compressors); compressor != nil {
+		caps.Compression = compressor.Name()
+		ack = append(ack, internal.HandshakeOption(internal.CompressionOption, compressor.Name()))
+
+		framing.compressor = compressor
+		framing.threshold = offer.CompressionThreshold
+		if framing.threshold <= 0 {
+			framing.threshold = DefaultCompressionThreshold
+		}
+	}
+
+	if offer.Checksums && parsed[internal.ChecksumOption] == internal.ChecksumName {
+		caps.Checksums = true
+		ack = append(ack, internal.HandshakeOption(internal.ChecksumOption, internal.ChecksumName))
+		framing.checksums = true
+	}
+
+	if offer.StreamChunkSize > 0 && parsed[internal.StreamOption] != "" {
+		caps.Streaming = true
+		ack = append(ack, internal.HandshakeOption(internal.StreamOption, strconv.Itoa(offer.StreamChunkSize)))
+		framing.streamChunkSize = offer.StreamChunkSize
+	}
+
+	if offer.RequestIDs && parsed[internal.RequestIDsOption] == "1" {
+		caps.RequestIDs = true
+		ack = append(ack, internal.HandshakeOption(internal.RequestIDsOption, "1"))
+		framing.requestIDs = true
+	}
+
+	if framing.compressor != nil || framing.checksums || framing.streamChunkSize > 0 || framing.requestIDs {
+		sw.pending.Store(framing)
+	}
+	sw.caps.Store(&caps)
+
+	return caps, ack
+}
+
+// Capabilities returns what the connection negotiated, or version 1 without
+// any capability before the handshake.
+func (sw *ServerWire) Capabilities() Capabilities {
+	if caps := sw.caps.Load(); caps != nil {
+		return *caps
+	}
+
+	return Capabilities{Version: 1}
+}
+
+// pendingFraming holds the negotiated framing changes until the handshake
+// result went out.
+type pendingFraming struct {
+	compressor      Compressor
+	threshold       int
+	checksums       bool
+	streamChunkSize int
+	requestIDs      bool
+}
+
+func (sw *ServerWire) applyPendingFraming() {
+	framing := sw.pending.Swap(nil)
+	if framing == nil {
+		return
+	}
+
+	if framing.compressor != nil {
+		sw.SetCompression(framing.compressor, framing.threshold)
+	}
+	if framing.checksums {
+		sw.SetChecksums(true)
+	}
+	if framing.streamChunkSize > 0 {
+		sw.SetStreaming(true)
+		sw.streamChunkSize.Store(int64(framing.streamChunkSize))
+	}
+	if framing.requestIDs {
+		sw.requestIDs.Store(true)
+	}
+}
+
+// pickCompressor returns the first of the comma-separated offered names
+// found among compressors.
+func pickCompressor(offered string, compressors []Compressor) Compressor {
+	if offered == "" {
+		return nil
+	}
+
+	for _, name := range strings.Split(offered, ",") {
+		for _, compressor := range compressors {
+			if compressor.Name() == name {
+				return compressor
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) handshakeArgs(mode string, multiplex bool) []string {
+	args := []string{
+		c.id,
+		mode,
+		internal.HandshakeOption(internal.VersionOption, strconv.Itoa(ProtocolVersion)),
+		internal.HandshakeOption(internal.MaxFrameOption, strconv.Itoa(c.maxResponseSize)),
+		internal.HandshakeOption(internal.StreamOption, "1"),
+	}
+
+	args = append(args, c.compression.offer()...)
+	if c.checksums {
+		args = append(args, internal.HandshakeOption(internal.ChecksumOption, internal.ChecksumName))
+	}
+	if multiplex {
+		args = append(args, internal.HandshakeOption(internal.RequestIDsOption, "1"))
+	}
+
+	return args
+}
+
+// handshake introduces the client on clientWire and turns on what the server
+// accepted of the offered capabilities. Servers predating capabilities reject
+// the extra arguments, so a rejected handshake is repeated in its original
+// form before giving up. With multiplex, request IDs are offered and, once
+// accepted, commands on clientWire go through a mux.
+func (c *Client) handshake(clientWire *ClientWire, mode string, multiplex bool) *wire.WireError {
+	resp, err := handshakeWith(clientWire, c.handshakeArgs(mode, multiplex))
+	if err != nil {
+		return err
+	}
+
+	if resp.Status == wire.Status_ERR {
+		if resp, err = handshakeWith(clientWire, []string{c.id, mode}); err != nil {
+			return err
+		}
+	}
+
+	if resp.Status == wire.Status_ERR {
+		return &wire.WireError{
+			Kind:  wire.NotEstablished,
+			Cause: fmt.Errorf("could not complete the handshake: %s", resp.Message),
+		}
+	}
+
+	options := internal.ParseHandshakeOptions(strings.Fields(resp.Message))
+	caps := Capabilities{Version: 1}
+	if v, err := strconv.Atoi(options[internal.VersionOption]); err == nil && v > 1 {
+		caps.Version = min(v, ProtocolVersion)
+	}
+	if n, err := strconv.Atoi(options[internal.MaxFrameOption]); err == nil && n > 0 {
+		caps.MaxFrameSize = n
+	}
+	if compressor := c.compression.accepted(options); compressor != nil {
+		caps.Compression = compressor.Name()
+		clientWire.SetCompression(compressor, c.compression.threshold)
+	}
+	if c.checksums && options[internal.ChecksumOption] == internal.ChecksumName {
+		caps.Checksums = true
+		clientWire.SetChecksums(true)
+	}
+	if n, err := strconv.Atoi(options[internal.StreamOption]); err == nil && n > 0 {
+		caps.Streaming = true
+		clientWire.SetStreaming(true)
+	}
+
+	clientWire.caps = caps
+	if multiplex && options[internal.RequestIDsOption] == "1" {
+		clientWire.caps.RequestIDs = true
+		clientWire.mux = newMux(clientWire, c.recorder)
+	}
+
+	return nil
+}
+
+func handshakeWith(clientWire *ClientWire, args []string) (*wire.Result, *wire.WireError) {
+	if err := clientWire.Send(&wire.Command{Cmd: "HANDSHAKE", Args: args}); err != nil {
+		return nil, err
+	}
+
+	return clientWire.Receive()
+}
+
+// Capabilities returns what the client's command connection negotiated with
+// the server it is connected to.
+func (c *Client) Capabilities() Capabilities {
+	c.wireMu.Lock()
+	defer c.wireMu.Unlock()
+
+	return c.mainWire.caps
+}
@@ -0,0 +1,36 @@
+package dicedb_test
+
+import (
+	"testing"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/sevendbmock"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func TestHandshakeFallsBackForOlderServers(t *testing.T) {
+	// arrange
+	srv, err := sevendbmock.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer srv.Close()
+
+	srv.Expect("HANDSHAKE").Times(1).Return(&wire.Result{
+		Status:  wire.Status_ERR,
+		Message: "HANDSHAKE expects 2 arguments",
+	})
+
+	// act
+	client, err := dicedb.NewClient(srv.Host(), srv.Port(), dicedb.WithID("legacy"))
+
+	// assert
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	if got := client.Capabilities(); got != (dicedb.Capabilities{Version: 1}) {
+		t.Errorf("Capabilities() = %+v, want version 1 without capabilities", got)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// ProcessFunc executes a command and returns its result. Failures are
+// wire.Status_ERR results, like Fire returns them.
+type ProcessFunc func(ctx context.Context, cmd *wire.Command) *wire.Result
+
+// ProcessPipelineFunc writes a batch of pipelined commands, whose results
+// arrive later.
+type ProcessPipelineFunc func(ctx context.Context, cmds []*wire.Command) error
+
+// Hook wraps how the client dials, executes commands and writes pipelined
+// batches. Each method receives the next step and returns what runs
+// instead; a hook that is not interested in a step returns next as it is.
+//
+// A process hook may change the command, or return a result without calling
+// next at all. A pipeline hook may change the commands of a batch but not
+// their number, since their results are matched by position. Returning an
+// error fails the batch and drops its connection.
+type Hook interface {
+	DialHook(next Dialer) Dialer
+	ProcessHook(next ProcessFunc) ProcessFunc
+	ProcessPipelineHook(next ProcessPipelineFunc) ProcessPipelineFunc
+}
+
+// hookChain holds the hooks added so far and the dial and process steps they
+// wrap, which are composed once per AddHook rather than per call.
+type hookChain struct {
+	hooks   []Hook
+	dial    Dialer
+	process ProcessFunc
+	primary ProcessFunc
+	async   ProcessFunc
+	stream  ProcessFunc
+}
+
+// AddHook wraps the client's dialing, command processing and pipelined
+// writes in hook. Hooks added earlier run first. Every command goes through
+// the process hooks once, with the caller's context: Fire, FireString and
+// FirePrimary, FireAsync and its typed variants, and GetStream, commands
+// routed to replicas included. The process hooks of FireAsync see a QUEUED
+// result, the future carries the server's answer, and those of GetStream a
+// result without the value, which is streamed. Commands that FireAsync or
+// auto-pipelining then write in batches go through the pipeline hooks as
+// well, with the context of the batch's first command minus its
+// cancellation. The replicas' connections are dialed through the hook too.
+func (c *Client) AddHook(hook Hook) {
+	c.hookMu.Lock()
+	defer c.hookMu.Unlock()
+
+	c.hooks.Store(c.newHookChain(append(slices.Clone(c.hooks.Load().hooks), hook)))
+
+	for _, r := range c.replicas.replicas {
+		if client := r.connected(); client != nil {
+			client.AddHook(hook)
+		}
+	}
+}
+
+func (c *Client) newHookChain(hooks []Hook) *hookChain {
+	chain := &hookChain{
+		hooks:   hooks,
+		dial:    c.dialConn,
+		process: c.processCommand,
+		primary: c.processPrimary,
+		async:   c.processAsync,
+		stream:  c.processStream,
+	}
+
+	for _, hook := range slices.Backward(hooks) {
+		chain.dial = hook.DialHook(chain.dial)
+		chain.process = hook.ProcessHook(chain.process)
+		chain.primary = hook.ProcessHook(chain.primary)
+		chain.async = hook.ProcessHook(chain.async)
+		chain.stream = hook.ProcessHook(chain.stream)
+	}
+
+	return chain
+}
+
+// dial opens a connection through the dial hooks.
+func (c *Client) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	return c.hooks.Load().dial(ctx, network, addr)
+}
+
+// processCommand is where the process hooks of Fire end.
+func (c *Client) processCommand(ctx context.Context, cmd *wire.Command) *wire.Result {
+	// Reads fall back to the primary when no replica is up or the one
+	// picked turned out to be down.
+	if len(c.replicas.replicas) > 0 && isReadOnly(cmd) {
+		if r := c.replicas.pick(); r != nil {
+			if res, ok := r.fire(ctx, cmd); ok {
+				return res
+			}
+		}
+	}
+
+	return c.fire(ctx, cmd, c.mainWire, c.restoreMainWire)
+}
+
+// processPrimary is where the process hooks of FirePrimary end.
+func (c *Client) processPrimary(ctx context.Context, cmd *wire.Command) *wire.Result {
+	return c.fire(ctx, cmd, c.mainWire, c.restoreMainWire)
+}
+
+// processAsync is where the process hooks of FireAsync end. It queues the
+// command and returns a QUEUED result, FireAsync hands out its future.
+func (c *Client) processAsync(ctx context.Context, cmd *wire.Command) *wire.Result {
+	f := c.sendAsync(ctx, cmd)
+
+	// A hook that passed on a context of its own gets the result it waits
+	// for instead.
+	call, ok := ctx.Value(asyncCallKey{}).(*asyncCall)
+	if !ok {
+		return f.Wait()
+	}
+
+	call.sent = f
+	call.queued = &wire.Result{Status: wire.Status_OK, Message: "QUEUED"}
+
+	return call.queued
+}
+
+// processStream is where the process hooks of GetStream end. The result it
+// returns leaves the value out, GetStream hands that out as a reader.
+func (c *Client) processStream(ctx context.Context, cmd *wire.Command) *wire.Result {
+	call, ok := ctx.Value(streamCallKey{}).(*streamCall)
+	if !ok {
+		return c.processCommand(ctx, cmd)
+	}
+
+	call.body, call.err = c.openStream(ctx, cmd)
+	if call.err != nil {
+		call.res = &wire.Result{Status: wire.Status_ERR, Message: call.err.Error()}
+	} else {
+		call.res = &wire.Result{Status: wire.Status_OK, Response: &wire.Result_GETRes{GETRes: &wire.GETRes{}}}
+	}
+
+	return call.res
+}
+
+// sendBatch writes cmds on clientWire through the pipeline hooks in hooks.
+func sendBatch(ctx context.Context, clientWire *ClientWire, hooks []Hook, cmds []*wire.Command) *wire.WireError {
+	if len(hooks) == 0 {
+		return clientWire.SendBatch(cmds)
+	}
+
+	sent, size := false, len(cmds)
+	next := ProcessPipelineFunc(func(ctx context.Context, cmds []*wire.Command) error {
+		if len(cmds) != size {
+			return fmt.Errorf("a pipeline hook changed the batch from %d to %d commands", size, len(cmds))
+		}
+
+		sent = true
+		if err := clientWire.SendBatch(cmds); err != nil {
+			return err
+		}
+		return nil
+	})
+	for _, hook := range slices.Backward(hooks) {
+		next = hook.ProcessPipelineHook(next)
+	}
+
+	err := next(ctx, cmds)
+	if err == nil && !sent {
+		err = errors.New("a pipeline hook dropped the batch")
+	}
+	if err == nil {
+		return nil
+	}
+
+	var wireErr *wire.WireError
+	if !errors.As(err, &wireErr) {
+		wireErr = &wire.WireError{Kind: wire.Terminated, Cause: err}
+	}
+
+	return wireErr
+}
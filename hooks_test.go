@@ -0,0 +1,150 @@
+package dicedb_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/server"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// recordingHook counts dials and pipelined batches, rewrites ALIAS to ECHO
+// and answers CACHED without asking the server.
+type recordingHook struct {
+	dials   atomic.Int64
+	batched atomic.Int64
+	fired   atomic.Int64
+	// processCaller and pipelineCaller are the last callerKey values the
+	// process and pipeline hooks saw.
+	processCaller  atomic.Value
+	pipelineCaller atomic.Value
+}
+
+type callerKey struct{}
+
+func (h *recordingHook) DialHook(next dicedb.Dialer) dicedb.Dialer {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		h.dials.Add(1)
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *recordingHook) ProcessHook(next dicedb.ProcessFunc) dicedb.ProcessFunc {
+	return func(ctx context.Context, cmd *wire.Command) *wire.Result {
+		h.fired.Add(1)
+		if caller, ok := ctx.Value(callerKey{}).(string); ok {
+			h.processCaller.Store(caller)
+		}
+		switch cmd.Cmd {
+		case "CACHED":
+			return &wire.Result{Status: wire.Status_OK, Message: "from cache"}
+		case "GET":
+			if cmd.Args[0] == "cached" {
+				return &wire.Result{Status: wire.Status_OK, Response: &wire.Result_GETRes{GETRes: &wire.GETRes{Value: "from cache"}}}
+			}
+		case "ALIAS":
+			cmd = &wire.Command{Cmd: "ECHO", Args: cmd.Args}
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h *recordingHook) ProcessPipelineHook(next dicedb.ProcessPipelineFunc) dicedb.ProcessPipelineFunc {
+	return func(ctx context.Context, cmds []*wire.Command) error {
+		h.batched.Add(int64(len(cmds)))
+		if caller, ok := ctx.Value(callerKey{}).(string); ok {
+			h.pipelineCaller.Store(caller)
+		}
+		return next(ctx, cmds)
+	}
+}
+
+func TestHooksWrapDialFireAndPipelines(t *testing.T) {
+	// arrange
+	_, addr := startServer(t, server.HandlerFunc(echo))
+
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	hook := &recordingHook{}
+	client.AddHook(hook)
+
+	// act
+	aliased := client.Fire(&wire.Command{Cmd: "ALIAS", Args: []string{"hello"}})
+	cached := client.Fire(&wire.Command{Cmd: "CACHED"})
+	ctx := context.WithValue(context.Background(), callerKey{}, "async caller")
+	async := client.FireAsync(ctx, &wire.Command{Cmd: "ECHO", Args: []string{"async"}}).Wait()
+	stream, streamErr := client.GetStream(context.Background(), "cached")
+
+	// assert
+	if got := aliased.GetECHORes().GetMessage(); got != "hello" {
+		t.Errorf("Fire(ALIAS) = %v, want the rewritten ECHO's result", aliased)
+	}
+
+	if cached.Message != "from cache" {
+		t.Errorf("Fire(CACHED) = %v, want the hook's result", cached)
+	}
+
+	if got := async.GetECHORes().GetMessage(); got != "async" {
+		t.Errorf("FireAsync() = %v, want the ECHO result", async)
+	}
+
+	if streamErr != nil {
+		t.Fatalf("GetStream() error = %v", streamErr)
+	}
+	if value, err := io.ReadAll(stream); err != nil || string(value) != "from cache" {
+		t.Errorf("GetStream() read %q, %v, want the hook's value", value, err)
+	}
+
+	if n := hook.fired.Load(); n != 4 {
+		t.Errorf("process hook ran %d times, want 4", n)
+	}
+
+	if hook.processCaller.Load() != "async caller" || hook.pipelineCaller.Load() != "async caller" {
+		t.Errorf("hooks saw callers %v and %v, want FireAsync's context in both", hook.processCaller.Load(), hook.pipelineCaller.Load())
+	}
+
+	// FireAsync dialed a connection of its own and wrote one batch on it.
+	if n := hook.dials.Load(); n != 1 {
+		t.Errorf("dial hook ran %d times, want 1", n)
+	}
+
+	if n := hook.batched.Load(); n != 1 {
+		t.Errorf("pipeline hook saw %d commands, want 1", n)
+	}
+}
+
+func TestHooksDialRestoredConnections(t *testing.T) {
+	// arrange
+	_, addr := startServer(t, server.HandlerFunc(echo))
+
+	tracker := &connTracker{}
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithDialer(tracker.dial))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	hook := &recordingHook{}
+	client.AddHook(hook)
+
+	// act
+	tracker.dropAll()
+	res := fireUntilOK(client, &wire.Command{Cmd: "ECHO", Args: []string{"after"}})
+
+	// assert
+	if got := res.GetECHORes().GetMessage(); got != "after" {
+		t.Errorf("Fire() after the connection dropped = %v, want after", res)
+	}
+
+	if n := hook.dials.Load(); n != 1 {
+		t.Errorf("dial hook ran %d times, want 1 for the restored connection", n)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package internal
+
+import "sync"
+
+const (
+	defaultBufferSize = 4 * 1024
+	// maxPooledBuffer keeps the occasional huge frame from pinning its
+	// buffer in the pool.
+	maxPooledBuffer = 64 * 1024
+)
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		buffer := make([]byte, 0, defaultBufferSize)
+		return &buffer
+	},
+}
+
+// getBuffer returns a pooled buffer of length size.
+func getBuffer(size int) *[]byte {
+	buffer := bufferPool.Get().(*[]byte)
+	if cap(*buffer) < size {
+		*buffer = make([]byte, size)
+	}
+	*buffer = (*buffer)[:size]
+
+	return buffer
+}
+
+func putBuffer(buffer *[]byte) {
+	if cap(*buffer) > maxPooledBuffer {
+		return
+	}
+
+	*buffer = (*buffer)[:0]
+	bufferPool.Put(buffer)
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func TestChecksummedFrames(t *testing.T) {
+	flate, _ := NewFlateCompressor(-1)
+
+	tests := []struct {
+		name       string
+		compressor Compressor
+		mangle     func(frame []byte)
+		wantErr    wire.ErrKind
+	}{
+		{name: "intact frame"},
+		{name: "intact compressed frame", compressor: flate},
+		{name: "mangled payload", mangle: func(frame []byte) { frame[PrefixSize+10] ^= 0x20 }, wantErr: wire.ChecksumMismatch},
+		{name: "mangled compressed payload", compressor: flate, mangle: func(frame []byte) { frame[PrefixSize+1] ^= 0x01 }, wantErr: wire.ChecksumMismatch},
+		{name: "mangled trailer", mangle: func(frame []byte) { frame[len(frame)-1] ^= 0xff }, wantErr: wire.ChecksumMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			conn := &loopbackConn{}
+			w := NewTCPWire(8192, conn)
+			w.SetChecksums(true)
+			if tt.compressor != nil {
+				w.SetCompression(tt.compressor, 64)
+			}
+			msg := bytes.Repeat([]byte("checksummed "), 100)
+
+			// act
+			sendErr := w.Send(msg)
+			if tt.mangle != nil {
+				tt.mangle(conn.Bytes())
+			}
+			got, err := w.Receive()
+
+			// assert
+			if sendErr != nil {
+				t.Fatalf("Send() error = %v", sendErr)
+			}
+
+			if tt.wantErr != 0 {
+				if err == nil || err.Kind != tt.wantErr {
+					t.Errorf("Receive() error = %v, want kind %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(got, msg) {
+				t.Errorf("Receive() = %d bytes, %v, want the %d bytes sent", len(got), err, len(msg))
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package internal
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DefaultCompressionThreshold is the payload size below which frames are
+// sent uncompressed, since compressing them rarely pays off.
+const DefaultCompressionThreshold = 1024
+
+// Compressor compresses frame payloads. Both peers must agree on Name, which
+// is what the handshake exchanges.
+type Compressor interface {
+	Name() string
+	// Compress appends the compressed src to dst.
+	Compress(dst, src []byte) ([]byte, error)
+	// Decompress appends the decompressed src to dst, failing once the
+	// output would exceed maxSize bytes.
+	Decompress(dst, src []byte, maxSize int) ([]byte, error)
+}
+
+var errDecompressedTooLarge = errors.New("decompressed message too large")
+
+type compression struct {
+	compressor Compressor
+	threshold  int
+}
+
+// appendWriter is an io.Writer appending to a slice.
+type appendWriter struct {
+	buffer []byte
+}
+
+func (w *appendWriter) Write(p []byte) (int, error) {
+	w.buffer = append(w.buffer, p...)
+	return len(p), nil
+}
+
+// readAllLimited appends everything r produces to dst, up to maxSize bytes.
+func readAllLimited(dst []byte, r io.Reader, maxSize int) ([]byte, error) {
+	w := &appendWriter{buffer: dst}
+	n, err := io.Copy(w, io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > int64(maxSize) {
+		return nil, fmt.Errorf("%w: more than %d bytes", errDecompressedTooLarge, maxSize)
+	}
+
+	return w.buffer, nil
+}
+
+type flateCompressor struct {
+	level   int
+	writers sync.Pool
+	readers sync.Pool
+}
+
+// NewFlateCompressor returns a raw DEFLATE compressor at the given
+// compress/flate level.
+func NewFlateCompressor(level int) (Compressor, error) {
+	if _, err := flate.NewWriter(io.Discard, level); err != nil {
+		return nil, err
+	}
+
+	return &flateCompressor{level: level}, nil
+}
+
+func (c *flateCompressor) Name() string {
+	return "flate"
+}
+
+func (c *flateCompressor) Compress(dst, src []byte) ([]byte, error) {
+	w := &appendWriter{buffer: dst}
+
+	fw, _ := c.writers.Get().(*flate.Writer)
+	if fw == nil {
+		fw, _ = flate.NewWriter(w, c.level)
+	} else {
+		fw.Reset(w)
+	}
+	defer c.writers.Put(fw)
+
+	if _, err := fw.Write(src); err != nil {
+		return nil, err
+	}
+	if err := fw.Close(); err != nil {
+		return nil, err
+	}
+
+	return w.buffer, nil
+}
+
+func (c *flateCompressor) Decompress(dst, src []byte, maxSize int) ([]byte, error) {
+	fr, _ := c.readers.Get().(io.ReadCloser)
+	if fr == nil {
+		fr = flate.NewReader(bytes.NewReader(src))
+	} else if err := fr.(flate.Resetter).Reset(bytes.NewReader(src), nil); err != nil {
+		return nil, err
+	}
+	defer c.readers.Put(fr)
+
+	return readAllLimited(dst, fr, maxSize)
+}
+
+type gzipCompressor struct {
+	level   int
+	writers sync.Pool
+	readers sync.Pool
+}
+
+// NewGzipCompressor returns a gzip compressor at the given compress/gzip
+// level.
+func NewGzipCompressor(level int) (Compressor, error) {
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		return nil, err
+	}
+
+	return &gzipCompressor{level: level}, nil
+}
+
+func (c *gzipCompressor) Name() string {
+	return "gzip"
+}
+
+func (c *gzipCompressor) Compress(dst, src []byte) ([]byte, error) {
+	w := &appendWriter{buffer: dst}
+
+	gw, _ := c.writers.Get().(*gzip.Writer)
+	if gw == nil {
+		gw, _ = gzip.NewWriterLevel(w, c.level)
+	} else {
+		gw.Reset(w)
+	}
+	defer c.writers.Put(gw)
+
+	if _, err := gw.Write(src); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+
+	return w.buffer, nil
+}
+
+func (c *gzipCompressor) Decompress(dst, src []byte, maxSize int) ([]byte, error) {
+	gr, _ := c.readers.Get().(*gzip.Reader)
+	if gr == nil {
+		var err error
+		if gr, err = gzip.NewReader(bytes.NewReader(src)); err != nil {
+			return nil, err
+		}
+	} else if err := gr.Reset(bytes.NewReader(src)); err != nil {
+		return nil, err
+	}
+	defer c.readers.Put(gr)
+
+	return readAllLimited(dst, gr, maxSize)
+}
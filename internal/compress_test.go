@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func TestCompressedFrames(t *testing.T) {
+	flate, _ := NewFlateCompressor(-1)
+	gzip, _ := NewGzipCompressor(-1)
+
+	random := make([]byte, 2048)
+	rand.Read(random)
+
+	tests := []struct {
+		name           string
+		compressor     Compressor
+		msg            []byte
+		maxMsgSize     int
+		wantCompressed bool
+		wantErr        wire.ErrKind
+	}{
+		{name: "flate", compressor: flate, msg: bytes.Repeat([]byte("a"), 4096), maxMsgSize: 8192, wantCompressed: true},
+		{name: "gzip", compressor: gzip, msg: bytes.Repeat([]byte("a"), 4096), maxMsgSize: 8192, wantCompressed: true},
+		{name: "below threshold", compressor: flate, msg: []byte("short"), maxMsgSize: 8192},
+		{name: "incompressible", compressor: flate, msg: random, maxMsgSize: 8192},
+		{name: "decompresses past max size", compressor: flate, msg: bytes.Repeat([]byte("a"), 4096), maxMsgSize: 1024, wantCompressed: true, wantErr: wire.CorruptMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			conn := &loopbackConn{}
+			w := NewTCPWire(tt.maxMsgSize, conn)
+			w.SetCompression(tt.compressor, 64)
+
+			// act
+			sendErr := w.Send(tt.msg)
+			compressed := ParsePrefix(conn.Bytes())&CompressedFlag != 0
+			got, err := w.Receive()
+
+			// assert
+			if sendErr != nil {
+				t.Fatalf("Send() error = %v", sendErr)
+			}
+			if compressed != tt.wantCompressed {
+				t.Errorf("frame compressed = %v, want %v", compressed, tt.wantCompressed)
+			}
+
+			if tt.wantErr != 0 {
+				if err == nil || err.Kind != tt.wantErr {
+					t.Errorf("Receive() error = %v, want kind %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(got, tt.msg) {
+				t.Errorf("Receive() = %d bytes, %v, want the %d bytes sent", len(got), err, len(tt.msg))
+			}
+		})
+	}
+}
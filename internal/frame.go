@@ -0,0 +1,32 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package internal
+
+import "encoding/binary"
+
+// PrefixSize is the size of the big-endian length prefix that precedes every
+// frame on the wire and every record in a WAL segment.
+const PrefixSize = 4 // bytes
+
+// CompressedFlag is set in the length prefix of a frame whose payload is
+// compressed. Sizes never reach it, so peers that did not negotiate
+// compression reject such a frame as too large.
+const CompressedFlag = 1 << 31
+
+// StreamFlag is set in the length prefix of each chunk of a streamed
+// message. The chunks' payloads concatenate to the message and a chunk of
+// zero bytes ends it. Chunks are never compressed.
+const StreamFlag = 1 << 30
+
+// DefaultStreamChunkSize is the size of the chunks a streamed message is cut
+// into.
+const DefaultStreamChunkSize = 1024 * 1024
+
+func PutPrefix(buffer []byte, size int) {
+	binary.BigEndian.PutUint32(buffer[:PrefixSize], uint32(size))
+}
+
+func ParsePrefix(buffer []byte) uint32 {
+	return binary.BigEndian.Uint32(buffer[:PrefixSize])
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func prefix(size int) []byte {
+	buffer := make([]byte, PrefixSize)
+	PutPrefix(buffer, size)
+
+	return buffer
+}
+
+func TestReceiveAllocatesAsPayloadArrives(t *testing.T) {
+	// arrange
+	client, server := net.Pipe()
+	defer server.Close()
+
+	w := NewTCPWire(32*1024*1024, client)
+	w.SetFrameTimeout(50 * time.Millisecond)
+
+	go server.Write(append(prefix(16*1024*1024), "only a few bytes"...))
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	// act
+	_, err := w.Receive()
+	runtime.ReadMemStats(&after)
+
+	// assert
+	if err == nil || err.Kind != wire.DeadlineExceeded {
+		t.Fatalf("Receive() error = %v, want DeadlineExceeded", err)
+	}
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1024*1024 {
+		t.Errorf("Receive() allocated %d bytes for a frame that never arrived", allocated)
+	}
+}
+
+func TestReceiveRejectsFrameOverFrameBudget(t *testing.T) {
+	// arrange
+	client, server := net.Pipe()
+	defer server.Close()
+
+	w := NewTCPWire(1024, client)
+	w.SetFrameBudget(100)
+
+	go server.Write(prefix(101))
+
+	// act
+	_, err := w.Receive()
+
+	// assert
+	if err == nil || err.Kind != wire.CorruptMessage {
+		t.Errorf("Receive() error = %v, want CorruptMessage", err)
+	}
+}
+
+func TestFrameTimeoutRestoresReadDeadline(t *testing.T) {
+	// arrange
+	client, server := net.Pipe()
+	defer server.Close()
+
+	w := NewTCPWire(1024, client)
+	w.SetFrameTimeout(time.Second)
+	if err := w.SetReadDeadline(time.Now().Add(50 * time.Millisecond)); err != nil {
+		t.Fatalf("SetReadDeadline() error = %v", err)
+	}
+
+	go server.Write(append(prefix(5), "hello"...))
+
+	// act
+	msg, firstErr := w.Receive()
+	start := time.Now()
+	_, secondErr := w.Receive()
+
+	// assert
+	if firstErr != nil || string(msg) != "hello" {
+		t.Fatalf("Receive() = %q, %v, want hello", msg, firstErr)
+	}
+
+	if secondErr == nil || secondErr.Kind != wire.DeadlineExceeded || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Receive() error = %v after %s, want the earlier read deadline to expire", secondErr, time.Since(start))
+	}
+}
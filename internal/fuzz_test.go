@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+const fuzzMaxMsgSize = 1024
+
+// The fuzz targets take a configuration next to the data, whose bits turn on
+// the options a connection may negotiate.
+const (
+	fuzzCompression = 1 << iota
+	fuzzChecksums
+	fuzzStreaming
+	fuzzRequestIDs
+
+	fuzzConfigs = 1 << iota
+)
+
+// configure turns on the options of config for both ends of a connection.
+func configure(t testing.TB, w *TCPWire, config byte) {
+	if config&fuzzCompression != 0 {
+		c, err := NewFlateCompressor(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.SetCompression(c, 16)
+	}
+	w.SetChecksums(config&fuzzChecksums != 0)
+	w.SetStreaming(config&fuzzStreaming != 0)
+}
+
+// noReadRetryDelay keeps the retries of truncated inputs from sleeping,
+// which would otherwise slow every run down.
+func noReadRetryDelay(f *testing.F) {
+	delay := readRetryDelay
+	readRetryDelay = 0
+	f.Cleanup(func() { readRetryDelay = delay })
+}
+
+// encode returns the bytes a peer configured with config sends for msg.
+func encode(f *testing.F, config byte, msg proto.Message) []byte {
+	conn := &loopbackConn{}
+	w := NewProtobufTCPWire(fuzzMaxMsgSize, conn)
+	configure(f, w.tcpWire, config)
+
+	var err *wire.WireError
+	switch {
+	case config&fuzzStreaming != 0 && config&fuzzRequestIDs != 0:
+		err = w.SendStreamWithID(7, msg, 8)
+	case config&fuzzStreaming != 0:
+		err = w.SendStream(msg, 8)
+	case config&fuzzRequestIDs != 0:
+		err = w.SendWithID(7, msg)
+	default:
+		err = w.Send(msg)
+	}
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	return conn.Bytes()
+}
+
+func fuzzSeeds(f *testing.F, configs byte) {
+	msg := &wire.Result{Status: wire.Status_OK, Message: strings.Repeat("OK", 32)}
+	for config := range configs {
+		frame := encode(f, config, msg)
+		f.Add(frame, config)
+		f.Add(frame[:len(frame)/2], config)
+	}
+
+	f.Add(prefix(0), byte(0))
+	f.Add(prefix(fuzzMaxMsgSize+1), byte(0))
+	f.Add(append(prefix(10), 1, 2, 3), byte(0))
+	f.Add([]byte{0, 0}, byte(0))
+}
+
+func FuzzTCPWireReceive(f *testing.F) {
+	noReadRetryDelay(f)
+	// Request IDs are part of the protobuf framing only.
+	fuzzSeeds(f, fuzzRequestIDs)
+
+	f.Fuzz(func(t *testing.T, data []byte, config byte) {
+		conn := &loopbackConn{}
+		conn.Write(data)
+		w := NewTCPWire(fuzzMaxMsgSize, conn)
+		configure(t, w, config%fuzzRequestIDs)
+
+		for {
+			msg, err := w.Receive()
+			if err != nil {
+				return
+			}
+			if len(msg) == 0 || len(msg) > fuzzMaxMsgSize {
+				t.Fatalf("Receive() returned a %d byte message", len(msg))
+			}
+		}
+	})
+}
+
+func FuzzProtobufTCPWireReceive(f *testing.F) {
+	noReadRetryDelay(f)
+	fuzzSeeds(f, fuzzConfigs)
+
+	f.Fuzz(func(t *testing.T, data []byte, config byte) {
+		conn := &loopbackConn{}
+		conn.Write(data)
+		w := NewProtobufTCPWire(fuzzMaxMsgSize, conn)
+		configure(t, w.tcpWire, config%fuzzConfigs)
+
+		for {
+			var err *wire.WireError
+			if config&fuzzRequestIDs != 0 {
+				_, err = w.ReceiveWithID(&wire.Result{})
+			} else {
+				err = w.Receive(&wire.Result{})
+			}
+			if err != nil {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package internal
+
+import "strings"
+
+// Handshake options follow the [id, mode] arguments of HANDSHAKE as
+// key=value arguments. The server acknowledges the ones it accepted as
+// key=value words in the result's message, which is free text otherwise.
+const (
+	VersionOption     = "version"
+	MaxFrameOption    = "max-frame"
+	CompressionOption = "compression"
+	ChecksumOption    = "checksum"
+	StreamOption      = "stream"
+	RequestIDsOption  = "request-ids"
+)
+
+func HandshakeOption(key, value string) string {
+	return key + "=" + value
+}
+
+// ParseHandshakeOptions collects the key=value words of args and skips
+// everything else.
+func ParseHandshakeOptions(args []string) map[string]string {
+	options := map[string]string{}
+	for _, arg := range args {
+		if key, value, ok := strings.Cut(arg, "="); ok && key != "" {
+			options[key] = value
+		}
+	}
+
+	return options
+}
@@ -4,15 +4,31 @@
 package internal
 
 import (
+	"encoding/binary"
+	"errors"
+	"io"
 	"net"
+	"time"
 
 	"github.com/sevenDatabase/SevenDB-go/wire"
 
 	"google.golang.org/protobuf/proto"
 )
 
+// RequestIDSize is the size of the request ID that precedes the marshaled
+// message in frames sent with SendWithID.
+const RequestIDSize = 4
+
+// Messages are marshaled straight after the space reserved for the length
+// prefix and unmarshaled from pooled read buffers, so a round trip allocates
+// little beyond the messages themselves.
+var (
+	marshalOptions   = proto.MarshalOptions{}
+	unmarshalOptions = proto.UnmarshalOptions{}
+)
+
 type ProtobufTCPWire struct {
-	tcpWire Wire
+	tcpWire *TCPWire
 }
 
 func NewProtobufTCPWire(maxMsgSize int, conn net.Conn) *ProtobufTCPWire {
@@ -22,30 +38,169 @@ func NewProtobufTCPWire(maxMsgSize int, conn net.Conn) *ProtobufTCPWire {
 }
 
 func (w *ProtobufTCPWire) Send(msg proto.Message) *wire.WireError {
-	buffer, err := proto.Marshal(msg)
+	buffer, err := w.marshal(PrefixSize, msg)
+	if err != nil {
+		return err
+	}
+	defer putBuffer(buffer)
+
+	return w.tcpWire.SendFrame(*buffer)
+}
+
+// SendWithID sends msg preceded by the request ID it belongs to.
+func (w *ProtobufTCPWire) SendWithID(id uint32, msg proto.Message) *wire.WireError {
+	buffer, err := w.marshal(PrefixSize+RequestIDSize, msg)
+	if err != nil {
+		return err
+	}
+	defer putBuffer(buffer)
+
+	binary.BigEndian.PutUint32((*buffer)[PrefixSize:], id)
+
+	return w.tcpWire.SendFrame(*buffer)
+}
+
+// SendBatch sends msgs in a single write.
+func (w *ProtobufTCPWire) SendBatch(msgs []proto.Message) *wire.WireError {
+	buffer := getBuffer(0)
+	defer putBuffer(buffer)
+
+	// Messages are marshaled back to back and framed once the buffer stopped
+	// growing.
+	ends := make([]int, len(msgs))
+	for i, msg := range msgs {
+		marshaled, err := marshalOptions.MarshalAppend(*buffer, msg)
+		if err != nil {
+			w.tcpWire.Close()
+			return &wire.WireError{Kind: wire.CorruptMessage, Cause: err}
+		}
+		*buffer = marshaled
+		ends[i] = len(marshaled)
+	}
+
+	payloads := make([][]byte, len(msgs))
+	start := 0
+	for i, end := range ends {
+		payloads[i] = (*buffer)[start:end]
+		start = end
+	}
+
+	return w.tcpWire.SendBatch(payloads)
+}
+
+// SendStream sends msg as a stream of chunks of at most chunkSize bytes.
+func (w *ProtobufTCPWire) SendStream(msg proto.Message, chunkSize int) *wire.WireError {
+	buffer, err := w.marshal(0, msg)
 	if err != nil {
+		return err
+	}
+	defer putBuffer(buffer)
+
+	return w.tcpWire.SendStream(*buffer, chunkSize)
+}
+
+// SendStreamWithID is SendStream for a message preceded by its request ID.
+func (w *ProtobufTCPWire) SendStreamWithID(id uint32, msg proto.Message, chunkSize int) *wire.WireError {
+	buffer, err := w.marshal(RequestIDSize, msg)
+	if err != nil {
+		return err
+	}
+	defer putBuffer(buffer)
+
+	binary.BigEndian.PutUint32(*buffer, id)
+
+	return w.tcpWire.SendStream(*buffer, chunkSize)
+}
+
+// marshal appends msg to a pooled buffer after reserve bytes.
+func (w *ProtobufTCPWire) marshal(reserve int, msg proto.Message) (*[]byte, *wire.WireError) {
+	buffer := getBuffer(reserve)
+
+	frame, err := marshalOptions.MarshalAppend(*buffer, msg)
+	if err != nil {
+		putBuffer(buffer)
 		w.tcpWire.Close()
-		return &wire.WireError{Kind: wire.CorruptMessage, Cause: err}
+		return nil, &wire.WireError{Kind: wire.CorruptMessage, Cause: err}
 	}
+	*buffer = frame
 
-	return w.tcpWire.Send(buffer)
+	return buffer, nil
 }
 
 func (w *ProtobufTCPWire) Receive(dst proto.Message) *wire.WireError {
-	buffer, err := w.tcpWire.Receive()
+	_, err := w.receive(dst, false)
+	return err
+}
+
+// ReceiveWithID receives a message sent with SendWithID or
+// SendStreamWithID and returns its request ID.
+func (w *ProtobufTCPWire) ReceiveWithID(dst proto.Message) (uint32, *wire.WireError) {
+	return w.receive(dst, true)
+}
+
+func (w *ProtobufTCPWire) receive(dst proto.Message, withID bool) (uint32, *wire.WireError) {
+	buffer, err := w.tcpWire.receivePooled()
 	if err != nil {
-		return err
+		return 0, err
+	}
+	defer putBuffer(buffer)
+
+	message := *buffer
+
+	var id uint32
+	if withID {
+		if len(message) < RequestIDSize {
+			w.tcpWire.Close()
+			return 0, &wire.WireError{Kind: wire.CorruptMessage, Cause: errors.New("message too short for a request ID")}
+		}
+		id = binary.BigEndian.Uint32(message)
+		message = message[RequestIDSize:]
 	}
 
-	uerr := proto.Unmarshal(buffer, dst)
+	// Unmarshal copies every string and bytes field out of the buffer, so it
+	// can go back to the pool.
+	uerr := unmarshalOptions.Unmarshal(message, dst)
 	if uerr != nil {
 		w.tcpWire.Close()
-		return &wire.WireError{Kind: wire.CorruptMessage, Cause: uerr}
+		return 0, &wire.WireError{Kind: wire.CorruptMessage, Cause: uerr}
 	}
 
-	return nil
+	return id, nil
+}
+
+// ReceiveStream returns a reader over the marshaled next message, see
+// TCPWire.ReceiveStream.
+func (w *ProtobufTCPWire) ReceiveStream() (io.ReadCloser, *wire.WireError) {
+	return w.tcpWire.ReceiveStream()
 }
 
 func (w *ProtobufTCPWire) Close() {
 	w.tcpWire.Close()
 }
+
+// SetFrameBudget caps the memory committed to each frame the wire reads,
+// see TCPWire.SetFrameBudget. Frames are read one at a time, so keeping the
+// messages a connection holds within a budget is up to the caller.
+func (w *ProtobufTCPWire) SetFrameBudget(bytes int) {
+	w.tcpWire.SetFrameBudget(bytes)
+}
+
+func (w *ProtobufTCPWire) SetFrameTimeout(d time.Duration) {
+	w.tcpWire.SetFrameTimeout(d)
+}
+
+func (w *ProtobufTCPWire) SetReadDeadline(t time.Time) error {
+	return w.tcpWire.SetReadDeadline(t)
+}
+
+func (w *ProtobufTCPWire) SetCompression(c Compressor, threshold int) {
+	w.tcpWire.SetCompression(c, threshold)
+}
+
+func (w *ProtobufTCPWire) SetChecksums(enabled bool) {
+	w.tcpWire.SetChecksums(enabled)
+}
+
+func (w *ProtobufTCPWire) SetStreaming(enabled bool) {
+	w.tcpWire.SetStreaming(enabled)
+}
@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+// loopbackConn reads back whatever was written to it.
+type loopbackConn struct {
+	bytes.Buffer
+}
+
+func (c *loopbackConn) Close() error                       { return nil }
+func (c *loopbackConn) LocalAddr() net.Addr                { return nil }
+func (c *loopbackConn) RemoteAddr() net.Addr               { return nil }
+func (c *loopbackConn) SetDeadline(t time.Time) error      { return nil }
+func (c *loopbackConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *loopbackConn) SetWriteDeadline(t time.Time) error { return nil }
+
+func TestProtobufRoundTripReusesBuffers(t *testing.T) {
+	// arrange
+	w := NewProtobufTCPWire(1024, &loopbackConn{})
+	first := &wire.Command{Cmd: "SET", Args: []string{"k", strings.Repeat("a", 100)}}
+	second := &wire.Command{Cmd: "GET", Args: []string{"k"}}
+
+	// act
+	gotFirst, gotSecond := &wire.Command{}, &wire.Command{}
+	errs := []*wire.WireError{
+		w.Send(first), w.Receive(gotFirst),
+		w.Send(second), w.Receive(gotSecond),
+	}
+
+	// assert
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("round trip error = %v", err)
+		}
+	}
+
+	if gotFirst.Args[1] != first.Args[1] || gotSecond.Cmd != "GET" {
+		t.Errorf("received %v and %v, want %v and %v", gotFirst, gotSecond, first, second)
+	}
+}
+
+func TestRequestIDsTravelWithMessages(t *testing.T) {
+	// arrange
+	w := NewProtobufTCPWire(4096, &loopbackConn{})
+	w.SetStreaming(true)
+	small := &wire.Command{Cmd: "GET", Args: []string{"k"}}
+	large := &wire.Command{Cmd: "SET", Args: []string{"k", strings.Repeat("v", 2048)}}
+
+	// act
+	sendErrs := []*wire.WireError{w.SendWithID(7, small), w.SendStreamWithID(8, large, 256)}
+	gotSmall, gotLarge := &wire.Command{}, &wire.Command{}
+	smallID, smallErr := w.ReceiveWithID(gotSmall)
+	largeID, largeErr := w.ReceiveWithID(gotLarge)
+
+	// assert
+	for _, err := range append(sendErrs, smallErr, largeErr) {
+		if err != nil {
+			t.Fatalf("round trip error = %v", err)
+		}
+	}
+
+	if smallID != 7 || gotSmall.Cmd != "GET" {
+		t.Errorf("ReceiveWithID() = %d, %v, want 7 and %v", smallID, gotSmall, small)
+	}
+	if largeID != 8 || gotLarge.Args[1] != large.Args[1] {
+		t.Errorf("ReceiveWithID() of the stream = %d, %.40v, want 8 and the streamed command", largeID, gotLarge)
+	}
+}
+
+func BenchmarkTCPWireSend(b *testing.B) {
+	conn := &loopbackConn{}
+	w := NewTCPWire(1024, conn)
+	msg := bytes.Repeat([]byte("x"), 256)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := w.Send(msg); err != nil {
+			b.Fatal(err)
+		}
+		conn.Reset()
+	}
+}
+
+func BenchmarkProtobufTCPWireRoundTrip(b *testing.B) {
+	w := NewProtobufTCPWire(1024, &loopbackConn{})
+	cmd := &wire.Command{Cmd: "SET", Args: []string{"key", strings.Repeat("v", 256)}}
+	dst := &wire.Command{}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := w.Send(cmd); err != nil {
+			b.Fatal(err)
+		}
+		if err := w.Receive(dst); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// countingLoopbackConn counts the writes made to it.
+type countingLoopbackConn struct {
+	loopbackConn
+	writes int
+}
+
+func (c *countingLoopbackConn) Write(p []byte) (int, error) {
+	c.writes++
+	return c.loopbackConn.Write(p)
+}
+
+func TestSendBatchWritesMessagesAtOnce(t *testing.T) {
+	// arrange
+	flate, _ := NewFlateCompressor(-1)
+	conn := &countingLoopbackConn{}
+	w := NewProtobufTCPWire(8192, conn)
+	w.SetCompression(flate, 64)
+	w.SetChecksums(true)
+
+	sent := []*wire.Command{
+		{Cmd: "GET", Args: []string{"k"}},
+		{Cmd: "SET", Args: []string{"k", strings.Repeat("compressible ", 50)}},
+		{Cmd: "INCR", Args: []string{"n"}},
+	}
+	msgs := make([]proto.Message, len(sent))
+	for i, cmd := range sent {
+		msgs[i] = cmd
+	}
+
+	// act
+	err := w.SendBatch(msgs)
+
+	// assert
+	if err != nil {
+		t.Fatalf("SendBatch() error = %v", err)
+	}
+
+	if conn.writes != 1 {
+		t.Errorf("SendBatch() made %d writes, want 1", conn.writes)
+	}
+
+	for i, want := range sent {
+		got := &wire.Command{}
+		if err := w.Receive(got); err != nil || !proto.Equal(got, want) {
+			t.Errorf("Receive() #%d = %v, %v, want %v", i, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package internal
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// ReceiveStream returns a reader over the next message. A streamed message
+// is read chunk by chunk as the reader is consumed, so it is not bounded by
+// maxMsgSize, and the wire is held until the reader is closed. Closing it
+// before the end of the stream closes the wire, since the rest of the stream
+// cannot be skipped cheaply. Regular messages are read in full right away.
+func (w *TCPWire) ReceiveStream() (io.ReadCloser, *wire.WireError) {
+	w.readMu.Lock()
+
+	size, flags, err := w.readSize()
+	if err != nil {
+		w.readMu.Unlock()
+		return nil, err
+	}
+
+	if flags&StreamFlag == 0 {
+		defer w.readMu.Unlock()
+
+		message, err := w.receiveFrame(size, flags, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return io.NopCloser(bytes.NewReader(message)), nil
+	}
+
+	return &streamReader{w: w, next: size, buffer: getBuffer(0)}, nil
+}
+
+// streamReader reads a stream one chunk at a time while holding readMu.
+type streamReader struct {
+	w *TCPWire
+	// next is the size of the chunk whose prefix was read last.
+	next   uint32
+	buffer *[]byte
+	// chunk is what is left to read of the current chunk.
+	chunk  []byte
+	err    error
+	closed bool
+}
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+
+	return n, nil
+}
+
+// fill reads the next chunk and the prefix of the one after it, or records
+// why the stream ended.
+func (r *streamReader) fill() {
+	if r.next == 0 {
+		if err := r.w.verifyChecksum(nil); err != nil {
+			r.err = err
+			return
+		}
+		r.err = io.EOF
+		return
+	}
+
+	chunk, err := r.w.readChecked(r.next, (*r.buffer)[:0])
+	if err != nil {
+		r.err = err
+		return
+	}
+	*r.buffer = chunk
+	r.chunk = chunk
+
+	if r.next, err = r.w.readChunkSize(); err != nil {
+		r.err = err
+	}
+}
+
+func (r *streamReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	if r.err != io.EOF {
+		r.w.Close()
+	}
+
+	putBuffer(r.buffer)
+	r.w.readMu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func TestStreamedFrames(t *testing.T) {
+	msg := bytes.Repeat([]byte("streamed "), 1000)
+
+	tests := []struct {
+		name       string
+		maxMsgSize int
+		checksums  bool
+		streaming  bool
+		wantErr    wire.ErrKind
+	}{
+		{name: "reassembled", maxMsgSize: 1024 * 1024, streaming: true},
+		{name: "reassembled with checksums", maxMsgSize: 1024 * 1024, checksums: true, streaming: true},
+		{name: "larger than max message size", maxMsgSize: 4096, streaming: true, wantErr: wire.CorruptMessage},
+		{name: "streaming not negotiated", maxMsgSize: 1024 * 1024, wantErr: wire.CorruptMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			w := NewTCPWire(tt.maxMsgSize, &loopbackConn{})
+			w.SetChecksums(tt.checksums)
+			w.SetStreaming(tt.streaming)
+
+			// act
+			sendErr := w.SendStream(msg, 1000)
+			got, err := w.Receive()
+
+			// assert
+			if sendErr != nil {
+				t.Fatalf("SendStream() error = %v", sendErr)
+			}
+
+			if tt.wantErr != 0 {
+				if err == nil || err.Kind != tt.wantErr {
+					t.Errorf("Receive() error = %v, want kind %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(got, msg) {
+				t.Errorf("Receive() = %d bytes, %v, want the %d bytes sent", len(got), err, len(msg))
+			}
+		})
+	}
+}
+
+func TestReceiveStreamReadsChunkByChunk(t *testing.T) {
+	// arrange
+	w := NewTCPWire(512, &loopbackConn{})
+	w.SetChecksums(true)
+	w.SetStreaming(true)
+
+	msg := bytes.Repeat([]byte("0123456789"), 1000)
+	if err := w.SendStream(msg, 500); err != nil {
+		t.Fatalf("SendStream() error = %v", err)
+	}
+	if err := w.Send([]byte("next")); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	// act
+	r, err := w.ReceiveStream()
+	if err != nil {
+		t.Fatalf("ReceiveStream() error = %v", err)
+	}
+	got, readErr := io.ReadAll(r)
+	r.Close()
+	next, nextErr := w.Receive()
+
+	// assert
+	if readErr != nil || !bytes.Equal(got, msg) {
+		t.Errorf("read %d bytes, %v, want the %d bytes sent past the max message size", len(got), readErr, len(msg))
+	}
+
+	if nextErr != nil || string(next) != "next" {
+		t.Errorf("Receive() after the stream = %q, %v, want next", next, nextErr)
+	}
+}
@@ -8,18 +8,19 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"log/slog"
 	"net"
+	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sevenDatabase/SevenDB-go/wire"
 )
 
-const prefixSize = 4 // bytes
-
 type Status int
 
 const (
@@ -27,53 +28,446 @@ const (
 	Closed Status = 2
 )
 
+// ChecksumName is how the CRC32C frame trailer is called in the handshake.
+const ChecksumName = "crc32c"
+
+// checksumSize is the size of the CRC32C trailer following each frame when
+// checksums are on. It covers the length prefix and the payload as sent.
+const checksumSize = 4
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// frameChunk is how much of a frame is read at a time.
+const frameChunk = 64 * 1024
+
+// readRetryDelay is how long a read that timed out or found the stream
+// ended early waits before its first retry. The delay doubles with each
+// retry.
+var readRetryDelay = 5 * time.Millisecond
+
 type TCPWire struct {
-	status     Status
-	maxMsgSize int
-	readMu     sync.Mutex
-	reader     *bufio.Reader
-	writeMu    sync.Mutex
-	conn       net.Conn
+	status       atomic.Int32
+	compression  atomic.Pointer[compression]
+	checksums    atomic.Bool
+	streaming    atomic.Bool
+	maxMsgSize   int
+	frameBudget  int
+	frameTimeout time.Duration
+	readMu       sync.Mutex
+	reader       *bufio.Reader
+	prefix       [PrefixSize]byte
+	trailer      [checksumSize]byte
+	writeMu      sync.Mutex
+	conn         net.Conn
+
+	deadlineMu    sync.Mutex
+	readDeadline  time.Time
+	frameDeadline time.Time
 }
 
 func NewTCPWire(maxMsgSize int, conn net.Conn) *TCPWire {
-	return &TCPWire{
-		status:     Open,
+	w := &TCPWire{
 		maxMsgSize: maxMsgSize,
 		conn:       conn,
 		reader:     bufio.NewReader(conn),
 	}
+	w.status.Store(int32(Open))
+
+	return w
+}
+
+// SetFrameBudget caps the memory the wire commits to a single frame, or a
+// streamed message, it is reading. Frames announcing more are rejected
+// before any of their payload is read. It does not bound the messages the
+// caller keeps once they were received. Zero, the default, allows up to
+// maxMsgSize.
+func (w *TCPWire) SetFrameBudget(bytes int) {
+	w.frameBudget = bytes
+}
+
+// SetFrameTimeout bounds how long the rest of a frame may take to arrive
+// once its length prefix has, so a peer cannot hold the wire with a frame it
+// never completes. Zero, the default, disables it.
+func (w *TCPWire) SetFrameTimeout(d time.Duration) {
+	w.frameTimeout = d
+}
+
+// SetCompression compresses every payload of at least threshold bytes sent
+// from now on, and allows receiving frames compressed with c. A nil c turns
+// compression off.
+func (w *TCPWire) SetCompression(c Compressor, threshold int) {
+	if c == nil {
+		w.compression.Store(nil)
+		return
+	}
+
+	w.compression.Store(&compression{compressor: c, threshold: threshold})
+}
+
+// SetChecksums turns the CRC32C trailer on or off for the frames sent and
+// received from now on. Both peers must agree on it.
+func (w *TCPWire) SetChecksums(enabled bool) {
+	w.checksums.Store(enabled)
+}
+
+// SetStreaming allows receiving streamed messages from now on. Peers only
+// stream once they agreed on it.
+func (w *TCPWire) SetStreaming(enabled bool) {
+	w.streaming.Store(enabled)
+}
+
+// SetReadDeadline sets the read deadline on the connection. Use it instead
+// of setting the deadline on the connection directly, so the frame timeout
+// can take the earlier of the two and restore this one afterwards.
+func (w *TCPWire) SetReadDeadline(t time.Time) error {
+	w.deadlineMu.Lock()
+	defer w.deadlineMu.Unlock()
+
+	w.readDeadline = t
+
+	return w.applyReadDeadline()
 }
 
 func (w *TCPWire) Send(msg []byte) *wire.WireError {
+	buffer := getBuffer(PrefixSize + len(msg))
+	defer putBuffer(buffer)
+
+	copy((*buffer)[PrefixSize:], msg)
+
+	return w.SendFrame(*buffer)
+}
+
+// SendFrame sends frame[PrefixSize:] as a message, writing the length prefix
+// into the space reserved for it at the start of frame. Callers that build
+// the message in place save Send's copy.
+func (w *TCPWire) SendFrame(frame []byte) *wire.WireError {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	if Status(w.status.Load()) == Closed {
+		return &wire.WireError{Kind: wire.Terminated, Cause: errors.New("trying to use closed wire")}
+	}
+
+	payload := frame[PrefixSize:]
+	if c := w.compression.Load(); c != nil && len(payload) >= c.threshold {
+		buffer := getBuffer(PrefixSize)
+		defer putBuffer(buffer)
+
+		compressed, err := c.compressor.Compress(*buffer, payload)
+		if err != nil {
+			return &wire.WireError{Kind: wire.CorruptMessage, Cause: fmt.Errorf("failed to compress message: %w", err)}
+		}
+		*buffer = compressed
+
+		// Incompressible payloads go out as they are.
+		if len(compressed) < len(frame) {
+			binary.BigEndian.PutUint32(compressed, uint32(len(compressed)-PrefixSize)|CompressedFlag)
+			*buffer = w.appendChecksum(compressed)
+			return w.write(*buffer)
+		}
+	}
+
+	PutPrefix(frame, len(payload))
+
+	return w.write(w.appendChecksum(frame))
+}
+
+// SendBatch sends msgs as consecutive messages in a single write, so a batch
+// of pipelined messages costs one syscall.
+func (w *TCPWire) SendBatch(msgs [][]byte) *wire.WireError {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	if Status(w.status.Load()) == Closed {
+		return &wire.WireError{Kind: wire.Terminated, Cause: errors.New("trying to use closed wire")}
+	}
+
+	buffer := getBuffer(0)
+	defer putBuffer(buffer)
+
+	for _, msg := range msgs {
+		frames, err := w.appendFrame(*buffer, msg)
+		if err != nil {
+			return err
+		}
+		*buffer = frames
+	}
+
+	return w.write(*buffer)
+}
+
+// appendFrame appends msg to dst framed the way SendFrame sends it.
+func (w *TCPWire) appendFrame(dst, msg []byte) ([]byte, *wire.WireError) {
+	start := len(dst)
+
+	if c := w.compression.Load(); c != nil && len(msg) >= c.threshold {
+		compressed, err := c.compressor.Compress(append(dst, make([]byte, PrefixSize)...), msg)
+		if err != nil {
+			return nil, &wire.WireError{Kind: wire.CorruptMessage, Cause: fmt.Errorf("failed to compress message: %w", err)}
+		}
+
+		if size := len(compressed) - start - PrefixSize; size < len(msg) {
+			binary.BigEndian.PutUint32(compressed[start:], uint32(size)|CompressedFlag)
+			return w.appendChecksumFrom(compressed, start), nil
+		}
+		dst = compressed[:start]
+	}
+
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(msg)))
+	dst = append(dst, msg...)
+
+	return w.appendChecksumFrom(dst, start), nil
+}
+
+// SendStream sends msg as a stream of chunks of at most chunkSize bytes, so
+// neither peer needs a frame as large as msg.
+func (w *TCPWire) SendStream(msg []byte, chunkSize int) *wire.WireError {
 	w.writeMu.Lock()
 	defer w.writeMu.Unlock()
 
-	if w.status == Closed {
+	if Status(w.status.Load()) == Closed {
 		return &wire.WireError{Kind: wire.Terminated, Cause: errors.New("trying to use closed wire")}
 	}
 
-	size := len(msg)
-	buffer := make([]byte, prefixSize+size)
+	buffer := getBuffer(PrefixSize + min(chunkSize, len(msg)) + checksumSize)
+	defer putBuffer(buffer)
 
-	prefix(size, buffer)
-	copy(buffer[prefixSize:], msg)
+	// The last chunk is empty and ends the stream.
+	for offset := 0; ; {
+		n := min(chunkSize, len(msg)-offset)
+		frame := (*buffer)[:PrefixSize+n]
+		binary.BigEndian.PutUint32(frame, uint32(n)|StreamFlag)
+		copy(frame[PrefixSize:], msg[offset:offset+n])
 
-	return w.write(buffer)
+		if err := w.write(w.appendChecksum(frame)); err != nil {
+			return err
+		}
+
+		if n == 0 {
+			return nil
+		}
+		offset += n
+	}
+}
+
+// appendChecksum appends the trailer to frame when checksums are on. Frames
+// come from pooled buffers, which usually have room for it.
+func (w *TCPWire) appendChecksum(frame []byte) []byte {
+	return w.appendChecksumFrom(frame, 0)
+}
+
+// appendChecksumFrom is appendChecksum for the frame starting at buffer[start].
+func (w *TCPWire) appendChecksumFrom(buffer []byte, start int) []byte {
+	if !w.checksums.Load() {
+		return buffer
+	}
+
+	return binary.BigEndian.AppendUint32(buffer, crc32.Checksum(buffer[start:], castagnoli))
+}
+
+// verifyChecksum reads the trailer of the frame just read and checks it
+// against the prefix and payload. Callers hold readMu.
+func (w *TCPWire) verifyChecksum(frame []byte) *wire.WireError {
+	if !w.checksums.Load() {
+		return nil
+	}
+
+	if err := w.readMessage(w.trailer[:]); err != nil {
+		return err
+	}
+
+	want := binary.BigEndian.Uint32(w.trailer[:])
+	got := crc32.Update(crc32.Checksum(w.prefix[:], castagnoli), castagnoli, frame)
+	if got != want {
+		w.Close()
+		return &wire.WireError{
+			Kind:  wire.ChecksumMismatch,
+			Cause: fmt.Errorf("frame checksum mismatch: got %08x, want %08x", got, want),
+		}
+	}
+
+	return nil
 }
 
 func (w *TCPWire) Receive() ([]byte, *wire.WireError) {
 	w.readMu.Lock()
 	defer w.readMu.Unlock()
 
-	size, err := w.readPrefix()
+	return w.receive(nil)
+}
+
+// receivePooled is Receive into a pooled buffer, which the caller returns
+// with putBuffer once it is done with the message.
+func (w *TCPWire) receivePooled() (*[]byte, *wire.WireError) {
+	w.readMu.Lock()
+	defer w.readMu.Unlock()
+
+	buffer := getBuffer(0)
+	message, err := w.receive(*buffer)
+	if err != nil {
+		putBuffer(buffer)
+		return nil, err
+	}
+	*buffer = message
+
+	return buffer, nil
+}
+
+// receive appends the next message to dst, reassembling it if it is
+// streamed. Callers hold readMu.
+func (w *TCPWire) receive(dst []byte) ([]byte, *wire.WireError) {
+	size, flags, err := w.readSize()
+	if err != nil {
+		return nil, err
+	}
+
+	if flags&StreamFlag != 0 {
+		return w.receiveStream(size, dst)
+	}
+
+	return w.receiveFrame(size, flags, dst)
+}
+
+// receiveFrame appends the payload of the frame whose prefix was just read
+// to dst, decompressing it if needed.
+func (w *TCPWire) receiveFrame(size, flags uint32, dst []byte) ([]byte, *wire.WireError) {
+	if flags&CompressedFlag == 0 {
+		return w.readChecked(size, dst)
+	}
+
+	scratch := getBuffer(0)
+	defer putBuffer(scratch)
+
+	frame, err := w.readChecked(size, *scratch)
 	if err != nil {
 		return nil, err
 	}
+	*scratch = frame
+
+	return w.decompress(dst, frame)
+}
+
+// receiveStream appends the chunks of a streamed message to dst. The whole
+// message is bounded like a single frame.
+func (w *TCPWire) receiveStream(size uint32, dst []byte) ([]byte, *wire.WireError) {
+	start, limit := len(dst), w.messageLimit()
+	for size > 0 {
+		if len(dst)-start+int(size) > limit {
+			w.Close()
+			return nil, &wire.WireError{
+				Kind:  wire.CorruptMessage,
+				Cause: fmt.Errorf("streamed message too large: more than %d bytes", limit),
+			}
+		}
+
+		var err *wire.WireError
+		if dst, err = w.readChecked(size, dst); err != nil {
+			return nil, err
+		}
+
+		if size, err = w.readChunkSize(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.verifyChecksum(nil); err != nil {
+		return nil, err
+	}
+
+	if len(dst) == start {
+		w.Close()
+		return nil, &wire.WireError{Kind: wire.CorruptMessage, Cause: errors.New("empty streamed message")}
+	}
+
+	return dst, nil
+}
+
+// readChunkSize reads the prefix of the next chunk of a stream.
+func (w *TCPWire) readChunkSize() (uint32, *wire.WireError) {
+	size, flags, err := w.readSize()
+	if err != nil {
+		return 0, err
+	}
+
+	if flags&StreamFlag == 0 {
+		w.Close()
+		return 0, &wire.WireError{Kind: wire.CorruptMessage, Cause: errors.New("stream interrupted by a regular frame")}
+	}
+
+	return size, nil
+}
+
+// readChecked is readFrame followed by the checksum verification.
+func (w *TCPWire) readChecked(size uint32, dst []byte) ([]byte, *wire.WireError) {
+	start := len(dst)
+	dst, err := w.readFrame(size, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.verifyChecksum(dst[start:]); err != nil {
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+// messageLimit is the largest message the wire accepts, bounded by
+// maxMsgSize and the frame budget.
+func (w *TCPWire) messageLimit() int {
+	if w.frameBudget > 0 {
+		return min(w.maxMsgSize, w.frameBudget)
+	}
+
+	return w.maxMsgSize
+}
+
+// decompress appends the decompressed frame to dst, bounded by maxMsgSize
+// and the frame budget like any other message.
+func (w *TCPWire) decompress(dst, frame []byte) ([]byte, *wire.WireError) {
+	message, err := w.compression.Load().compressor.Decompress(dst, frame, w.messageLimit())
+	if err == nil && len(message) == 0 {
+		err = errors.New("empty message")
+	}
+	if err != nil {
+		w.Close()
+		return nil, &wire.WireError{Kind: wire.CorruptMessage, Cause: fmt.Errorf("failed to decompress message: %w", err)}
+	}
 
-	if size <= 0 {
+	return message, nil
+}
+
+// readSize reads the next length prefix and checks it against maxMsgSize
+// and the frame budget. It returns the size and the flags that apply:
+// compressed frames are only accepted once compression was negotiated and
+// stream chunks once streaming was.
+func (w *TCPWire) readSize() (uint32, uint32, *wire.WireError) {
+	size, err := w.readPrefix()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var flags uint32
+	if size&CompressedFlag != 0 && w.compression.Load() != nil {
+		flags |= CompressedFlag
+	}
+	if size&StreamFlag != 0 && w.streaming.Load() {
+		flags |= StreamFlag
+	}
+	size &^= flags
+
+	if flags == CompressedFlag|StreamFlag {
+		w.Close()
+		return 0, 0, &wire.WireError{
+			Kind:  wire.CorruptMessage,
+			Cause: errors.New("compressed stream chunk"),
+		}
+	}
+
+	// Only a stream ends with an empty chunk.
+	if size <= 0 && flags&StreamFlag == 0 {
 		w.Close()
-		return nil, &wire.WireError{
+		return 0, 0, &wire.WireError{
 			Kind:  wire.CorruptMessage,
 			Cause: fmt.Errorf("invalid message size: %d", size),
 		}
@@ -81,26 +475,95 @@ func (w *TCPWire) Receive() ([]byte, *wire.WireError) {
 
 	if size > uint32(w.maxMsgSize) {
 		w.Close()
-		return nil, &wire.WireError{
+		return 0, 0, &wire.WireError{
 			Kind:  wire.CorruptMessage,
 			Cause: fmt.Errorf("message too large: %d bytes (max: %d)", size, w.maxMsgSize),
 		}
 	}
 
-	buffer, err := w.readMessage(size)
-	if err != nil {
-		return nil, err
+	if w.frameBudget > 0 && size > uint32(w.frameBudget) {
+		w.Close()
+		return 0, 0, &wire.WireError{
+			Kind:  wire.CorruptMessage,
+			Cause: fmt.Errorf("message of %d bytes exceeds the frame budget of %d bytes", size, w.frameBudget),
+		}
+	}
+
+	return size, flags, nil
+}
+
+// readFrame appends a payload of size bytes to buffer, reusing its capacity.
+// The buffer grows as the payload arrives, at most doubling, so it never
+// holds much more than twice what was read and an announced size costs
+// nothing until the peer actually sends the bytes.
+func (w *TCPWire) readFrame(size uint32, buffer []byte) ([]byte, *wire.WireError) {
+	w.beginFrame()
+	defer w.endFrame()
+
+	end := len(buffer) + int(size)
+	for len(buffer) < end {
+		n := min(end-len(buffer), frameChunk)
+		if free := cap(buffer) - len(buffer); free < n {
+			grown := make([]byte, len(buffer), min(max(2*cap(buffer), len(buffer)+n), end))
+			copy(grown, buffer)
+			buffer = grown
+		}
+
+		chunk := buffer[len(buffer) : len(buffer)+n]
+		if err := w.readMessage(chunk); err != nil {
+			return nil, err
+		}
+
+		buffer = buffer[:len(buffer)+n]
 	}
 
 	return buffer, nil
 }
 
+func (w *TCPWire) beginFrame() {
+	if w.frameTimeout <= 0 {
+		return
+	}
+
+	w.deadlineMu.Lock()
+	defer w.deadlineMu.Unlock()
+
+	w.frameDeadline = time.Now().Add(w.frameTimeout)
+	if err := w.applyReadDeadline(); err != nil {
+		slog.Warn("failed to set frame read deadline", "error", err)
+	}
+}
+
+func (w *TCPWire) endFrame() {
+	if w.frameTimeout <= 0 {
+		return
+	}
+
+	w.deadlineMu.Lock()
+	defer w.deadlineMu.Unlock()
+
+	w.frameDeadline = time.Time{}
+	if Status(w.status.Load()) == Open {
+		w.applyReadDeadline()
+	}
+}
+
+// applyReadDeadline sets the earlier of the read and frame deadlines on the
+// connection. Callers hold deadlineMu.
+func (w *TCPWire) applyReadDeadline() error {
+	deadline := w.readDeadline
+	if !w.frameDeadline.IsZero() && (deadline.IsZero() || w.frameDeadline.Before(deadline)) {
+		deadline = w.frameDeadline
+	}
+
+	return w.conn.SetReadDeadline(deadline)
+}
+
 func (w *TCPWire) Close() {
-	if w.status == Closed {
+	if !w.status.CompareAndSwap(int32(Open), int32(Closed)) {
 		return
 	}
 
-	w.status = Closed
 	err := w.conn.Close()
 	if err != nil {
 		slog.Warn("error closing network connection", "error", err)
@@ -110,22 +573,22 @@ func (w *TCPWire) Close() {
 }
 
 func (w *TCPWire) readPrefix() (uint32, *wire.WireError) {
-	buffer := make([]byte, prefixSize)
-	delay := 5 * time.Millisecond
+	buffer := w.prefix[:]
+	delay := readRetryDelay
 	const maxRetries = 5
 
 	var lastErr error
 	for attempt := 0; attempt < maxRetries; attempt++ {
 		_, err := io.ReadFull(w.reader, buffer)
 		if err == nil {
-			return binary.BigEndian.Uint32(buffer), nil
+			return ParsePrefix(buffer), nil
 		}
 
 		lastErr = err
 
-		// Retry only on timeout or temporary errors
+		// Retry only on timeout or temporary errors, an expired deadline is final
 		var opErr *net.OpError
-		if errors.As(err, &opErr) && (opErr.Timeout() || opErr.Temporary()) {
+		if errors.As(err, &opErr) && (opErr.Timeout() || opErr.Temporary()) && !errors.Is(err, os.ErrDeadlineExceeded) {
 			// Exponential backoff: doubling the delay for each retry
 			time.Sleep(delay)
 			delay = delay * 2
@@ -143,6 +606,9 @@ func (w *TCPWire) readPrefix() (uint32, *wire.WireError) {
 	case errors.Is(lastErr, io.ErrUnexpectedEOF):
 		w.Close()
 		return 0, &wire.WireError{Kind: wire.Terminated, Cause: lastErr}
+	case errors.Is(lastErr, os.ErrDeadlineExceeded):
+		w.Close()
+		return 0, &wire.WireError{Kind: wire.DeadlineExceeded, Cause: lastErr}
 	case strings.Contains(lastErr.Error(), "use of closed network connection"):
 		w.Close()
 		return 0, &wire.WireError{Kind: wire.Terminated, Cause: lastErr}
@@ -160,23 +626,23 @@ func (w *TCPWire) readPrefix() (uint32, *wire.WireError) {
 	}
 }
 
-func (w *TCPWire) readMessage(size uint32) ([]byte, *wire.WireError) {
-	buffer := make([]byte, size)
-	delay := 5 * time.Millisecond
+func (w *TCPWire) readMessage(buffer []byte) *wire.WireError {
+	delay := readRetryDelay
 	const maxRetries = 5
 
 	var lastErr error
 	for attempt := 0; attempt < maxRetries; attempt++ {
 		_, err := io.ReadFull(w.reader, buffer)
 		if err == nil {
-			return buffer, nil
+			return nil
 		}
 
 		lastErr = err
 
-		// Retry only on timeout or temporary errors or EOF in case of partial write
+		// Retry only on timeout or temporary errors or EOF in case of partial write,
+		// an expired deadline is final
 		var opErr *net.OpError
-		if (errors.As(err, &opErr) && (opErr.Timeout() || opErr.Temporary())) || errors.Is(err, io.EOF) {
+		if ((errors.As(err, &opErr) && (opErr.Timeout() || opErr.Temporary())) || errors.Is(err, io.EOF)) && !errors.Is(err, os.ErrDeadlineExceeded) {
 			// Exponential backoff: doubling the delay for each retry
 			time.Sleep(delay)
 			delay = delay * 2
@@ -190,25 +656,28 @@ func (w *TCPWire) readMessage(size uint32) ([]byte, *wire.WireError) {
 	// Classify the final error
 	switch {
 	case errors.Is(lastErr, io.EOF):
-		w.status = Closed
-		return buffer, &wire.WireError{Kind: wire.CorruptMessage, Cause: lastErr}
+		w.status.Store(int32(Closed))
+		return &wire.WireError{Kind: wire.CorruptMessage, Cause: lastErr}
 	case errors.Is(lastErr, io.ErrUnexpectedEOF):
-		w.status = Closed
-		return buffer, &wire.WireError{Kind: wire.Terminated, Cause: lastErr}
+		w.status.Store(int32(Closed))
+		return &wire.WireError{Kind: wire.Terminated, Cause: lastErr}
+	case errors.Is(lastErr, os.ErrDeadlineExceeded):
+		w.Close()
+		return &wire.WireError{Kind: wire.DeadlineExceeded, Cause: lastErr}
 	case strings.Contains(lastErr.Error(), "use of closed network connection"):
-		w.status = Closed
-		return buffer, &wire.WireError{Kind: wire.Terminated, Cause: lastErr}
+		w.status.Store(int32(Closed))
+		return &wire.WireError{Kind: wire.Terminated, Cause: lastErr}
 	case func() bool {
 		var opErr *net.OpError
 		return errors.As(lastErr, &opErr) && (opErr.Timeout() || opErr.Temporary())
 	}():
 		// This case was already checked during retries, but it falls back here if it's a fatal error
-		w.status = Closed
-		return buffer, &wire.WireError{Kind: wire.Terminated, Cause: lastErr}
+		w.status.Store(int32(Closed))
+		return &wire.WireError{Kind: wire.Terminated, Cause: lastErr}
 	default:
 		// Handle other unknown error types by marking the status as closed
-		w.status = Closed
-		return buffer, &wire.WireError{Kind: wire.Terminated, Cause: lastErr}
+		w.status.Store(int32(Closed))
+		return &wire.WireError{Kind: wire.Terminated, Cause: lastErr}
 	}
 }
 
@@ -231,14 +700,19 @@ func (w *TCPWire) write(buffer []byte) *wire.WireError {
 		if err != nil && !errors.Is(err, io.ErrShortWrite) {
 			lastRetryableErr = err
 			if errors.Is(err, io.ErrClosedPipe) {
-				w.status = Closed
+				w.status.Store(int32(Closed))
 				return &wire.WireError{Kind: wire.Terminated, Cause: err}
 			}
 
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				w.Close()
+				return &wire.WireError{Kind: wire.DeadlineExceeded, Cause: err}
+			}
+
 			var opErr *net.OpError
 			if errors.As(err, &opErr) && (opErr.Timeout() || opErr.Temporary()) {
 				if backoffRetries > maxBackoffRetries {
-					w.status = Closed
+					w.status.Store(int32(Closed))
 					return &wire.WireError{
 						Kind:  wire.Terminated,
 						Cause: fmt.Errorf("max backoff retries reached: %w", lastRetryableErr),
@@ -251,13 +725,13 @@ func (w *TCPWire) write(buffer []byte) *wire.WireError {
 				continue
 			}
 
-			w.status = Closed
+			w.status.Store(int32(Closed))
 			return &wire.WireError{Kind: wire.Terminated, Cause: err}
 		}
 
 		if isPartial {
 			if partialWriteRetries >= maxPartialWriteRetries {
-				w.status = Closed
+				w.status.Store(int32(Closed))
 				return &wire.WireError{
 					Kind:  wire.Terminated,
 					Cause: fmt.Errorf("max partial write retries reached: %w", err),
@@ -271,7 +745,3 @@ func (w *TCPWire) write(buffer []byte) *wire.WireError {
 
 	return nil
 }
-
-func prefix(msgSize int, buffer []byte) {
-	binary.BigEndian.PutUint32(buffer[:prefixSize], uint32(msgSize))
-}
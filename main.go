@@ -1,30 +1,68 @@
 package dicedb
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
 )
 
-const maxResponseSize = 32 * 1024 * 1024 // 32 MB
+const defaultMaxResponseSize = 32 * 1024 * 1024 // 32 MB
 
 type Client struct {
 	id           string
 	mainMu       sync.Mutex
+	wireMu       sync.Mutex
 	mainRetrier  *Retrier
 	mainWire     *ClientWire
 	watchRetrier *Retrier
 	watchWire    *ClientWire
 	watchCh      chan *wire.Result
-	host         string
-	port         int
+	endpoints    endpoints
+	replicas     replicaSet
+	dialer       Dialer
+	sockOpts     []func(*net.TCPConn) error
+	onConnect    []ConnHook
+	onClose      []ConnHook
+	recorder     *Recorder
+	frameTimeout time.Duration
+	compression  compressionConfig
+	checksums    bool
+	multiplex    bool
+	// streamWire carries GetStream when the main connection is multiplexed.
+	// streamMu is held while a stream is read from it.
+	streamWire *ClientWire
+	streamMu   sync.Mutex
+	// async carries FireAsync, and Fire with auto-pipelining, when the main
+	// connection is not multiplexed.
+	async   *pipe
+	asyncMu sync.Mutex
+	// pipelining routes Fire through async too once its maxBatch is set.
+	pipelining pipelining
+	// maxResponseSize bounds the responses read in full, streamed or not.
+	maxResponseSize int
+	optErr          error
+	// hooks is read on every command, hookMu serializes AddHook.
+	hooks  atomic.Pointer[hookChain]
+	hookMu sync.Mutex
+
+	healthCheckInterval time.Duration
+	done                chan struct{}
+	closeOnce           sync.Once
 }
 
+// ConnHook observes a connection of the client together with the client ID.
+type ConnHook func(conn net.Conn, clientID string)
+
 type option func(*Client)
 
 func WithID(id string) option {
@@ -33,87 +71,371 @@ func WithID(id string) option {
 	}
 }
 
-func NewClient(host string, port int, opts ...option) (*Client, error) {
-	mainRetrier := NewRetrier(3, 5*time.Second)
-	clientWire, err := ExecuteWithResult(mainRetrier, []wire.ErrKind{wire.NotEstablished}, func() (*ClientWire, *wire.WireError) {
-		return NewClientWire(maxResponseSize, host, port)
-	}, noop)
+// WithDialer replaces the dialer used for every connection the client opens,
+// including reconnects and the watch connection.
+func WithDialer(dialer Dialer) option {
+	return func(c *Client) {
+		c.dialer = dialer
+	}
+}
 
-	if err != nil {
-		if err.Kind == wire.NotEstablished {
-			return nil, fmt.Errorf("could not connect to dicedb server after %d retries: %w", mainRetrier.maxRetries, err)
+// OnConnect registers a hook that runs after every successful dial.
+func OnConnect(hook ConnHook) option {
+	return func(c *Client) {
+		c.onConnect = append(c.onConnect, hook)
+	}
+}
+
+// OnClose registers a hook that runs before a connection is closed.
+func OnClose(hook ConnHook) option {
+	return func(c *Client) {
+		c.onClose = append(c.onClose, hook)
+	}
+}
+
+// WithKeepAlive enables TCP keepalive with the given period. A zero period
+// disables keepalive.
+func WithKeepAlive(period time.Duration) option {
+	return withSockOpt(func(conn *net.TCPConn) error {
+		if period == 0 {
+			return conn.SetKeepAlive(false)
 		}
 
-		return nil, fmt.Errorf("unexpected error when establishing server connection, report this to dicedb maintainers: %w", err)
+		if err := conn.SetKeepAlive(true); err != nil {
+			return fmt.Errorf("failed to set keepalive: %w", err)
+		}
+		if err := conn.SetKeepAlivePeriod(period); err != nil {
+			return fmt.Errorf("failed to set keepalive period: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// WithNoDelay sets TCP_NODELAY, which controls whether small writes are
+// sent at once instead of being coalesced by the kernel.
+func WithNoDelay(noDelay bool) option {
+	return withSockOpt(func(conn *net.TCPConn) error {
+		if err := conn.SetNoDelay(noDelay); err != nil {
+			return fmt.Errorf("failed to set TCP_NODELAY: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// WithReadBuffer sets the size of the kernel's receive buffer.
+func WithReadBuffer(bytes int) option {
+	return withSockOpt(func(conn *net.TCPConn) error {
+		if err := conn.SetReadBuffer(bytes); err != nil {
+			return fmt.Errorf("failed to set read buffer: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// WithWriteBuffer sets the size of the kernel's send buffer.
+func WithWriteBuffer(bytes int) option {
+	return withSockOpt(func(conn *net.TCPConn) error {
+		if err := conn.SetWriteBuffer(bytes); err != nil {
+			return fmt.Errorf("failed to set write buffer: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// WithFrameTimeout bounds how long the rest of a response may take to arrive
+// once its length prefix has. Zero, the default, disables it.
+func WithFrameTimeout(d time.Duration) option {
+	return func(c *Client) {
+		c.frameTimeout = d
+	}
+}
+
+// WithMaxResponseSize bounds the size of the responses the client reads in
+// full, 32 MB by default. GetStream reads values of any size.
+func WithMaxResponseSize(bytes int) option {
+	return func(c *Client) {
+		if bytes <= 0 {
+			c.optErr = fmt.Errorf("invalid max response size %d", bytes)
+			return
+		}
+
+		c.maxResponseSize = bytes
+	}
+}
+
+// WithChecksums offers the server a CRC32C trailer on every frame, so
+// payloads mangled on the way are reported as wire.ChecksumMismatch instead
+// of being parsed. Servers that do not support it keep the connection
+// without checksums.
+func WithChecksums() option {
+	return func(c *Client) {
+		c.checksums = true
+	}
+}
+
+// WithRequestIDs offers the server request IDs on the main connection. Once
+// accepted, commands of concurrent goroutines share the connection instead
+// of waiting for each other, and their results may arrive in any order.
+// Servers that do not support it keep serving one command at a time.
+func WithRequestIDs() option {
+	return func(c *Client) {
+		c.multiplex = true
+	}
+}
+
+// pipelining configures how long a pipe waits for more commands before a
+// write and how many it sends at most in one.
+type pipelining struct {
+	window   time.Duration
+	maxBatch int
+}
+
+// WithAutoPipelining sends the commands of concurrent Fire calls together.
+// A command waits up to window for others to join it, up to maxBatch of them
+// go out in a single write, and each caller gets its own result back. Fire
+// then shares the connection FireAsync uses. It does not apply to a
+// multiplexed connection, where commands are in flight together anyway.
+func WithAutoPipelining(window time.Duration, maxBatch int) option {
+	return func(c *Client) {
+		if window < 0 || maxBatch <= 0 {
+			c.optErr = fmt.Errorf("invalid auto-pipelining window %s or batch size %d", window, maxBatch)
+			return
+		}
+
+		c.pipelining = pipelining{window: window, maxBatch: maxBatch}
+	}
+}
+
+func withSockOpt(set func(*net.TCPConn) error) option {
+	return func(c *Client) {
+		c.sockOpts = append(c.sockOpts, set)
 	}
+}
 
+func NewClient(host string, port int, opts ...option) (*Client, error) {
 	client := &Client{
-		mainRetrier: mainRetrier,
-		mainWire:    clientWire,
-		host:        host,
-		port:        port,
+		dialer:          defaultDialer,
+		maxResponseSize: defaultMaxResponseSize,
+		done:            make(chan struct{}),
 	}
+	client.endpoints.add(Endpoint{Host: host, Port: port})
 
 	for _, opt := range opts {
 		opt(client)
 	}
 
+	if client.optErr != nil {
+		return nil, client.optErr
+	}
+
 	if client.id == "" {
 		client.id = uuid.New().String()
 	}
 
-	if resp := client.Fire(&wire.Command{
-		Cmd:  "HANDSHAKE",
-		Args: []string{client.id, "command"},
-	}); resp.Status == wire.Status_ERR {
-		return nil, fmt.Errorf("could not complete the handshake: %s", resp.Message)
+	// A replica's client starts out with the hooks of its primary.
+	if client.hooks.Load() == nil {
+		client.hooks.Store(client.newHookChain(nil))
+	}
+
+	mainRetrier := NewRetrier(3, 5*time.Second)
+	clientWire, err := ExecuteWithResult(mainRetrier, []wire.ErrKind{wire.NotEstablished}, client.newWire, noop)
+
+	if err != nil {
+		if err.Kind == wire.NotEstablished {
+			return nil, fmt.Errorf("could not connect to dicedb server after %d retries: %w", mainRetrier.maxRetries, err)
+		}
+
+		return nil, fmt.Errorf("unexpected error when establishing server connection, report this to dicedb maintainers: %w", err)
+	}
+
+	client.mainRetrier = mainRetrier
+	client.mainWire = clientWire
+	client.endpoints.activate(clientWire.endpoint)
+
+	if err := client.handshake(clientWire, "command", client.multiplex); err != nil {
+		client.closeWire(clientWire)
+		return nil, err
+	}
+
+	client.connectReplicas()
+
+	if client.healthCheckInterval > 0 {
+		go client.healthCheck()
 	}
 
 	return client, nil
 }
 
-func (c *Client) fire(cmd *wire.Command, clientWire *ClientWire) *wire.Result {
+func (c *Client) fire(ctx context.Context, cmd *wire.Command, clientWire *ClientWire, restore func() *wire.WireError) *wire.Result {
+	res, _ := c.fireChecked(ctx, cmd, clientWire, restore)
+	return res
+}
+
+// fireChecked is fire also returning the connection failure behind an error
+// result, which is nil when the server sent the result. ctx only travels
+// with the command to the pipeline hooks, Fire does not stop waiting.
+func (c *Client) fireChecked(ctx context.Context, cmd *wire.Command, clientWire *ClientWire, restore func() *wire.WireError) (*wire.Result, *wire.WireError) {
+	c.wireMu.Lock()
+	caps, m := clientWire.caps, clientWire.mux
+	c.wireMu.Unlock()
+
+	if res := oversized(cmd, caps); res != nil {
+		return res, nil
+	}
+
+	if m != nil {
+		return c.fireMultiplexed(ctx, cmd, clientWire, m, restore)
+	}
+
+	if c.pipelining.maxBatch > 0 && clientWire == c.mainWire {
+		return c.firePipelined(ctx, cmd)
+	}
+
 	c.mainMu.Lock()
 	defer c.mainMu.Unlock()
 
 	err := ExecuteVoid(c.mainRetrier, []wire.ErrKind{wire.Terminated}, func() *wire.WireError {
 		return clientWire.Send(cmd)
-	}, c.restoreMainWire)
+	}, restore)
 
 	if err != nil {
-		var message string
+		return sendFailure(err), err
+	}
 
-		switch err.Kind {
-		case wire.Terminated:
-			message = fmt.Sprintf("failied to send command, connection terminated: %s", err.Cause)
-		case wire.CorruptMessage:
-			message = fmt.Sprintf("failied to send command, corrupt message: %s", err.Cause)
-		default:
-			message = fmt.Sprintf("failed to send command: unrecognized error, this should be reported to DiceDB maintainers: %s", err.Cause)
-		}
+	resp, err := clientWire.Receive()
+	if err != nil {
+		// The stream is no longer usable, closing it makes the next Send
+		// fail with wire.Terminated and restore (or fail over) the connection.
+		clientWire.Close()
+		return receiveFailure(err), err
+	}
 
-		return &wire.Result{
-			Status:  wire.Status_ERR,
-			Message: message,
+	// Recording under mainMu keeps the log in the order the server applied
+	// the commands.
+	if c.recorder != nil {
+		c.recorder.record(cmd, resp)
+	}
+
+	return resp, nil
+}
+
+// fireMultiplexed sends cmd through m and waits for its result without
+// holding mainMu, so commands of other goroutines are in flight meanwhile.
+func (c *Client) fireMultiplexed(ctx context.Context, cmd *wire.Command, clientWire *ClientWire, m *mux, restore func() *wire.WireError) (*wire.Result, *wire.WireError) {
+	f := newFuture(ctx, cmd)
+	if err := c.sendMultiplexed(f, clientWire, m, restore); err != nil {
+		if err == errNotMultiplexed {
+			return c.fireChecked(ctx, cmd, clientWire, restore)
 		}
+		return sendFailure(err), err
 	}
 
-	resp, err := clientWire.Receive()
-	if err != nil {
-		return &wire.Result{
-			Status:  wire.Status_ERR,
-			Message: fmt.Sprintf("failed to receive response: %s", err.Cause),
+	return f.wait()
+}
+
+// errNotMultiplexed reports that the connection was restored to a server
+// that did not accept request IDs again.
+var errNotMultiplexed = &wire.WireError{Kind: wire.Terminated, Cause: errors.New("restored connection is not multiplexed")}
+
+// sendMultiplexed sends the command of f through m, restoring the
+// connection once when m is gone.
+func (c *Client) sendMultiplexed(f *Future, clientWire *ClientWire, m *mux, restore func() *wire.WireError) *wire.WireError {
+	err := m.send(f)
+	if err == nil || err.Kind != wire.Terminated {
+		return err
+	}
+
+	// The reader may have failed f with the other pending commands while it
+	// was being sent. Part of it may have reached the server then, so it is
+	// not sent again and f keeps that failure.
+	if f.completed.Load() {
+		return nil
+	}
+
+	if err := c.restoreMux(clientWire, m, restore); err != nil {
+		return err
+	}
+
+	if m = c.muxOf(clientWire); m == nil {
+		return errNotMultiplexed
+	}
+
+	return m.send(f)
+}
+
+// restoreMux restores the connection m ran on, unless another caller already
+// did.
+func (c *Client) restoreMux(clientWire *ClientWire, m *mux, restore func() *wire.WireError) *wire.WireError {
+	c.mainMu.Lock()
+	defer c.mainMu.Unlock()
+
+	if c.muxOf(clientWire) != m {
+		return nil
+	}
+
+	return restore()
+}
+
+func (c *Client) muxOf(clientWire *ClientWire) *mux {
+	c.wireMu.Lock()
+	defer c.wireMu.Unlock()
+
+	return clientWire.mux
+}
+
+// oversized rejects a command the server cannot accept, which would only get
+// the connection closed on us.
+func oversized(cmd *wire.Command, caps Capabilities) *wire.Result {
+	if max := caps.MaxFrameSize; max > 0 {
+		if size := proto.Size(cmd); size > max {
+			return &wire.Result{
+				Status:  wire.Status_ERR,
+				Message: fmt.Sprintf("command of %d bytes exceeds the server's max frame size of %d bytes", size, max),
+			}
 		}
 	}
 
-	return resp
+	return nil
+}
+
+func sendFailure(err *wire.WireError) *wire.Result {
+	var message string
+
+	switch err.Kind {
+	case wire.Terminated:
+		message = fmt.Sprintf("failied to send command, connection terminated: %s", err.Cause)
+	case wire.CorruptMessage:
+		message = fmt.Sprintf("failied to send command, corrupt message: %s", err.Cause)
+	default:
+		message = fmt.Sprintf("failed to send command: unrecognized error, this should be reported to DiceDB maintainers: %s", err.Cause)
+	}
+
+	return &wire.Result{
+		Status:  wire.Status_ERR,
+		Message: message,
+	}
+}
+
+func receiveFailure(err *wire.WireError) *wire.Result {
+	return &wire.Result{
+		Status:  wire.Status_ERR,
+		Message: fmt.Sprintf("failed to receive response: %s", err.Cause),
+	}
 }
 
 func (c *Client) Fire(cmd *wire.Command) *wire.Result {
-	return c.fire(cmd, c.mainWire)
+	return c.hooks.Load().process(context.Background(), cmd)
 }
 
 func (c *Client) FireString(cmdStr string) *wire.Result {
+	return c.Fire(parseCommand(cmdStr))
+}
+
+func parseCommand(cmdStr string) *wire.Command {
 	cmdStr = strings.TrimSpace(cmdStr)
 	tokens := strings.Split(cmdStr, " ")
 
@@ -123,10 +445,10 @@ func (c *Client) FireString(cmdStr string) *wire.Result {
 		args = tokens[1:]
 	}
 
-	return c.Fire(&wire.Command{
+	return &wire.Command{
 		Cmd:  cmd,
 		Args: args,
-	})
+	}
 }
 
 func (c *Client) WatchCh() (<-chan *wire.Result, error) {
@@ -137,16 +459,14 @@ func (c *Client) WatchCh() (<-chan *wire.Result, error) {
 
 	c.watchCh = make(chan *wire.Result)
 	c.watchRetrier = NewRetrier(5, 5*time.Second)
-	c.watchWire, err = NewClientWire(maxResponseSize, c.host, c.port)
+	c.watchWire, err = c.newWire()
 	if err != nil {
 		return nil, fmt.Errorf("Failed to establish watch connection with server: %w", err)
 	}
 
-	if resp := c.fire(&wire.Command{
-		Cmd:  "HANDSHAKE",
-		Args: []string{c.id, "watch"},
-	}, c.watchWire); resp.Status == wire.Status_ERR {
-		return nil, fmt.Errorf("could not complete the handshake: %s", resp.Message)
+	if err := c.handshake(c.watchWire, "watch", false); err != nil {
+		c.closeWire(c.watchWire)
+		return nil, err
 	}
 
 	go c.watch()
@@ -154,51 +474,187 @@ func (c *Client) WatchCh() (<-chan *wire.Result, error) {
 	return c.watchCh, nil
 }
 
+// watch owns watchCh and closes it once the watch connection is gone, either
+// because the client was closed or because it could not be restored.
 func (c *Client) watch() {
+	defer close(c.watchCh)
+	defer c.closeWire(c.watchWire)
+
 	for {
 		resp, err := ExecuteWithResult(c.watchRetrier, []wire.ErrKind{wire.Terminated}, c.watchWire.Receive, c.restoreWatchWire)
 
 		if err != nil {
-			slog.Error("watch connection has been terminated due to an error", "err", err)
-			close(c.watchCh)
-			c.watchWire.Close()
-			break
+			if !c.closed() {
+				slog.Error("watch connection has been terminated due to an error", "err", err)
+			}
+			return
 		}
 
-		c.watchCh <- resp
+		select {
+		case c.watchCh <- resp:
+		case <-c.done:
+			return
+		}
 	}
 }
 
+// Close closes the client's connections. Closing it again does nothing.
 func (c *Client) Close() {
-	c.mainWire.Close()
-	if c.watchCh != nil {
-		c.watchWire.Close()
-		close(c.watchCh)
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.closeReplicas()
+		c.closeWire(c.mainWire)
+		if c.watchCh != nil {
+			c.closeWire(c.watchWire)
+		}
+
+		c.wireMu.Lock()
+		streamWire := c.streamWire
+		c.wireMu.Unlock()
+		if streamWire != nil {
+			c.closeWire(streamWire)
+		}
+
+		c.closeAsync()
+	})
+}
+
+func (c *Client) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
 	}
 }
 
 func (c *Client) restoreMainWire() *wire.WireError {
-	return c.restoreWire(c.mainWire)
+	return c.restoreWire(c.mainWire, "command")
 }
 
 func (c *Client) restoreWatchWire() *wire.WireError {
-	return c.restoreWire(c.watchWire)
+	return c.restoreWire(c.watchWire, "watch")
 }
 
-func (c *Client) restoreWire(dst *ClientWire) *wire.WireError { // nolint:staticcheck
+// restoreWire replaces dst with a connection to the next healthy endpoint and
+// repeats the handshake on it, so the server knows the client again.
+func (c *Client) restoreWire(dst *ClientWire, mode string) *wire.WireError {
+	if c.closed() {
+		return &wire.WireError{Kind: wire.Terminated, Cause: errors.New("client is closed")}
+	}
+
 	slog.Warn("trying to restore connection with server...")
-	var err *wire.WireError
 
-	dst, err = NewClientWire(maxResponseSize, c.host, c.port) // nolint:ineffassign,staticcheck
+	restored, err := c.openWire(dst)
 	if err != nil {
 		slog.Warn("failed to restore connection with server", "error", err)
 		return err
 	}
 
-	slog.Info("connection restored successfully")
+	// A connection keeps the framing it started with, so only a multiplexed
+	// one offers request IDs again.
+	if err := c.handshake(restored, mode, dst.mux != nil); err != nil {
+		slog.Warn("failed to restore connection with server", "error", err)
+		c.closeWire(restored)
+		return err
+	}
+
+	c.closeWire(dst)
+
+	c.wireMu.Lock()
+	*dst = *restored
+	c.wireMu.Unlock()
+
+	if dst == c.mainWire {
+		c.endpoints.activate(dst.endpoint)
+	}
+
+	slog.Info("connection restored successfully", "endpoint", c.ActiveEndpoint())
 	return nil
 }
 
+func (c *Client) newWire() (*ClientWire, *wire.WireError) {
+	return c.openWire(nil)
+}
+
+// openWire connects to an endpoint, failing over from the endpoint of
+// failed unless it is nil.
+func (c *Client) openWire(failed *ClientWire) (*ClientWire, *wire.WireError) {
+	idx := -1
+	if failed != nil {
+		idx = failed.endpoint
+	}
+
+	clientWire, err := c.dialEndpoints(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hook := range c.onConnect {
+		hook(clientWire.conn, c.id)
+	}
+
+	return clientWire, nil
+}
+
+func (c *Client) closeWire(clientWire *ClientWire) {
+	c.wireMu.Lock()
+	defer c.wireMu.Unlock()
+
+	if clientWire.closed {
+		return
+	}
+	clientWire.closed = true
+
+	for _, hook := range c.onClose {
+		hook(clientWire.conn, c.id)
+	}
+
+	clientWire.Close()
+}
+
+// dialConn is where the dial hooks end.
+func (c *Client) dialConn(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := c.dialer(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.sockOpts) == 0 {
+		return conn, nil
+	}
+
+	tcpConn, ok := tcpConnOf(conn)
+	if !ok {
+		slog.Warn("socket options not applied, the dialer returned no TCP connection", "type", fmt.Sprintf("%T", conn))
+		return conn, nil
+	}
+
+	for _, set := range c.sockOpts {
+		if err := set(tcpConn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+// tcpConnOf finds the TCP connection under conn, looking through wrappers
+// that expose the connection they wrap like tls.Conn does.
+func tcpConnOf(conn net.Conn) (*net.TCPConn, bool) {
+	for {
+		switch c := conn.(type) {
+		case *net.TCPConn:
+			return c, true
+		case interface{ NetConn() net.Conn }:
+			conn = c.NetConn()
+		default:
+			return nil, false
+		}
+	}
+}
+
 func noop() *wire.WireError {
 	return nil
 }
@@ -1,9 +1,14 @@
 package dicedb
 
 import (
+	"context"
 	"errors"
+	"net"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/sevenDatabase/SevenDB-go/faultconn"
 	"github.com/sevenDatabase/SevenDB-go/wire"
 )
 
@@ -85,3 +90,106 @@ func TestClient_Fire(t *testing.T) {
 		})
 	}
 }
+
+func TestTCPConnOfLooksThroughWrappers(t *testing.T) {
+	// arrange
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	tests := []struct {
+		name   string
+		conn   net.Conn
+		wantOK bool
+	}{
+		{name: "tcp conn", conn: conn, wantOK: true},
+		{name: "faultconn wrapper", conn: faultconn.Wrap(conn, faultconn.Config{}), wantOK: true},
+		{name: "opaque wrapper", conn: struct{ net.Conn }{conn}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// act
+			got, ok := tcpConnOf(tt.conn)
+
+			// assert
+			if ok != tt.wantOK || (ok && got != conn) {
+				t.Errorf("tcpConnOf() = %v, %v, want ok = %v", got, ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSendMultiplexedDoesNotResendFailedFuture(t *testing.T) {
+	// arrange
+	lost := &wire.WireError{Kind: wire.Terminated, Cause: errors.New("connection reset")}
+	m := &mux{err: lost}
+	f := newFuture(context.Background(), &wire.Command{Cmd: "INCR", Args: []string{"k"}})
+	f.fail(lost, receiveFailure(lost))
+
+	restored := 0
+	restore := func() *wire.WireError {
+		restored++
+		return nil
+	}
+	clientWire := &ClientWire{mux: m}
+	c := &Client{mainWire: clientWire}
+
+	// act
+	err := c.sendMultiplexed(f, clientWire, m, restore)
+
+	// assert
+	if err != nil || restored != 0 {
+		t.Errorf("sendMultiplexed() = %v after %d restores, want nil without restoring", err, restored)
+	}
+
+	if res, err := f.wait(); err != lost || res.Status != wire.Status_ERR {
+		t.Errorf("wait() = %v, %v, want the original failure", res, err)
+	}
+}
+
+func TestBindContextLeavesNoDeadlineAfterStop(t *testing.T) {
+	// arrange
+	entered, stopped, finished := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	var last atomic.Value
+	setDeadline := func(deadline time.Time) error {
+		if !deadline.IsZero() {
+			// The cancellation callback: hold it until stop returned, or for
+			// a while when stop waits for it.
+			close(entered)
+			defer close(finished)
+			select {
+			case <-stopped:
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+		last.Store(deadline)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stop, err := bindContext(ctx, setDeadline, time.Time{})
+	if err != nil {
+		t.Fatalf("bindContext() error = %v", err)
+	}
+
+	// act
+	cancel()
+	<-entered
+	stop()
+	close(stopped)
+	<-finished
+
+	// assert
+	if deadline := last.Load().(time.Time); !deadline.IsZero() {
+		t.Errorf("deadline after stop = %v, want none", deadline)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/sevenDatabase/SevenDB-go/internal"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// mux lets many goroutines share a connection that negotiated request IDs.
+// Each command is sent with an ID of its own and a reader goroutine
+// completes the future waiting for that ID, in whatever order the server
+// answers.
+type mux struct {
+	// conn is the connection the mux was started on. Restoring overwrites
+	// the ClientWire in place, so the mux must not read the stream from it.
+	conn     *internal.ProtobufTCPWire
+	recorder *Recorder
+
+	mu      sync.Mutex
+	nextID  uint32
+	pending map[uint32]*Future
+	// err is why the reader stopped, after which no command is accepted.
+	err *wire.WireError
+}
+
+func newMux(clientWire *ClientWire, recorder *Recorder) *mux {
+	m := &mux{
+		conn:     clientWire.ProtobufTCPWire,
+		recorder: recorder,
+		pending:  make(map[uint32]*Future),
+	}
+	go m.read()
+
+	return m
+}
+
+// send sends the command of f, whose result completes f. It fails with
+// wire.Terminated once the connection is gone.
+func (m *mux) send(f *Future) *wire.WireError {
+	m.mu.Lock()
+	if m.err != nil {
+		m.mu.Unlock()
+		return &wire.WireError{Kind: wire.Terminated, Cause: fmt.Errorf("connection lost: %w", m.err)}
+	}
+
+	m.nextID++
+	id := m.nextID
+	m.pending[id] = f
+	m.mu.Unlock()
+
+	if err := m.conn.SendWithID(id, f.cmd); err != nil {
+		m.mu.Lock()
+		delete(m.pending, id)
+		m.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+func (m *mux) read() {
+	for {
+		res := &wire.Result{}
+		id, err := m.conn.ReceiveWithID(res)
+		if err != nil {
+			m.fail(err)
+			return
+		}
+
+		m.mu.Lock()
+		f, ok := m.pending[id]
+		delete(m.pending, id)
+		m.mu.Unlock()
+
+		if !ok {
+			slog.Warn("dropping result for unknown request", "id", id)
+			continue
+		}
+
+		// The server executed the command even when nobody waits for it
+		// anymore.
+		if m.recorder != nil {
+			m.recorder.record(f.cmd, res)
+		}
+		f.complete(res)
+	}
+}
+
+// fail completes every pending future with err and closes the connection,
+// so the next command restores it.
+func (m *mux) fail(err *wire.WireError) {
+	m.mu.Lock()
+	m.err = err
+	pending := m.pending
+	m.pending = nil
+	m.mu.Unlock()
+
+	for _, f := range pending {
+		f.fail(err, receiveFailure(err))
+	}
+
+	m.conn.Close()
+}
+
+// broken reports whether the reader stopped.
+func (m *mux) broken() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.err != nil
+}
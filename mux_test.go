@@ -0,0 +1,189 @@
+package dicedb_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/server"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// startServer serves h on a local port until the test ends.
+func startServer(t *testing.T, h server.Handler) (*server.Server, *net.TCPAddr) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	srv := &server.Server{Handler: h}
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+
+	return srv, listener.Addr().(*net.TCPAddr)
+}
+
+func echo(ctx context.Context, cmd *wire.Command) *wire.Result {
+	return &wire.Result{
+		Status:   wire.Status_OK,
+		Response: &wire.Result_ECHORes{ECHORes: &wire.ECHORes{Message: cmd.Args[0]}},
+	}
+}
+
+// answeredBy echoes name to every command, telling apart which server
+// answered.
+func answeredBy(name string) server.Handler {
+	return server.HandlerFunc(func(ctx context.Context, cmd *wire.Command) *wire.Result {
+		return echo(ctx, &wire.Command{Cmd: cmd.Cmd, Args: []string{name}})
+	})
+}
+
+// connTracker dials connections it can later drop all at once, like a
+// network failure would.
+type connTracker struct {
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (d *connTracker) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.conns = append(d.conns, conn)
+	d.mu.Unlock()
+
+	return conn, nil
+}
+
+func (d *connTracker) dropAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, conn := range d.conns {
+		conn.Close()
+	}
+	d.conns = nil
+}
+
+func TestRequestIDsAnswerOutOfOrder(t *testing.T) {
+	// arrange
+	started, release := make(chan struct{}), make(chan struct{})
+	router := server.NewRouter()
+	router.HandleFunc("SLOW", func(ctx context.Context, cmd *wire.Command) *wire.Result {
+		close(started)
+		<-release
+		return echo(ctx, cmd)
+	})
+	router.HandleFunc("ECHO", echo)
+	_, addr := startServer(t, router)
+
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithRequestIDs())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// act
+	slow := make(chan *wire.Result)
+	go func() {
+		slow <- client.Fire(&wire.Command{Cmd: "SLOW", Args: []string{"slow"}})
+	}()
+	<-started
+
+	fast := make(chan *wire.Result)
+	go func() {
+		fast <- client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{"fast"}})
+	}()
+
+	var fastRes, slowRes *wire.Result
+	select {
+	case fastRes = <-fast:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ECHO waited for the slow command ahead of it")
+	}
+	close(release)
+	slowRes = <-slow
+
+	// assert
+	if !client.Capabilities().RequestIDs {
+		t.Errorf("Capabilities() = %+v, want request IDs", client.Capabilities())
+	}
+
+	if fastRes.GetECHORes().GetMessage() != "fast" || slowRes.GetECHORes().GetMessage() != "slow" {
+		t.Errorf("Fire() = %v and %v, want each command's own result", fastRes, slowRes)
+	}
+}
+
+func TestMultiplexedConnectionIsRestored(t *testing.T) {
+	// arrange
+	_, addr := startServer(t, server.HandlerFunc(echo))
+
+	tracker := &connTracker{}
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithRequestIDs(), dicedb.WithDialer(tracker.dial))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	if res := client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{"before"}}); res.Status != wire.Status_OK {
+		t.Fatalf("Fire() = %v, want OK", res)
+	}
+
+	// act
+	tracker.dropAll()
+	res := client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{"after"}})
+
+	// assert
+	if got := res.GetECHORes().GetMessage(); got != "after" {
+		t.Errorf("Fire() after the connection dropped = %v, want after", res)
+	}
+
+	if !client.Capabilities().RequestIDs {
+		t.Errorf("Capabilities() = %+v, want the restored connection multiplexed", client.Capabilities())
+	}
+}
+
+func TestMultiplexedConnectionFailsOver(t *testing.T) {
+	// arrange
+	primary, primaryAddr := startServer(t, answeredBy("primary"))
+	_, standbyAddr := startServer(t, answeredBy("standby"))
+
+	client, err := dicedb.NewClient(primaryAddr.IP.String(), primaryAddr.Port, dicedb.WithRequestIDs(), dicedb.WithSeeds(standbyAddr.String()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	if got := client.Fire(&wire.Command{Cmd: "ECHO"}).GetECHORes().GetMessage(); got != "primary" {
+		t.Fatalf("Fire() answered by %q, want primary", got)
+	}
+
+	// act
+	primary.Close()
+
+	// A command the primary may have received is failed rather than resent.
+	var res *wire.Result
+	for attempt := 0; attempt < 3; attempt++ {
+		if res = client.Fire(&wire.Command{Cmd: "ECHO"}); res.Status == wire.Status_OK {
+			break
+		}
+	}
+
+	// assert
+	if got := res.GetECHORes().GetMessage(); got != "standby" {
+		t.Errorf("Fire() after the primary went away = %v, want the standby's answer", res)
+	}
+
+	if got := client.ActiveEndpoint().String(); got != standbyAddr.String() {
+		t.Errorf("ActiveEndpoint() = %s, want %s", got, standbyAddr)
+	}
+}
@@ -0,0 +1,57 @@
+package dicedb_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/sevendbmock"
+)
+
+func TestConnectionOptions(t *testing.T) {
+	// arrange
+	srv, err := sevendbmock.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer srv.Close()
+
+	dialed := 0
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed++
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+
+	var connected, closed []string
+	client, err := dicedb.NewClient(srv.Host(), srv.Port(),
+		dicedb.WithID("client-1"),
+		dicedb.WithDialer(dialer),
+		dicedb.WithNoDelay(true),
+		dicedb.WithKeepAlive(0),
+		dicedb.WithReadBuffer(64*1024),
+		dicedb.WithWriteBuffer(64*1024),
+		dicedb.OnConnect(func(conn net.Conn, clientID string) { connected = append(connected, clientID) }),
+		dicedb.OnClose(func(conn net.Conn, clientID string) { closed = append(closed, clientID) }),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	// act
+	client.Close()
+
+	// assert
+	if dialed != 1 {
+		t.Errorf("dialer called %d times, want 1", dialed)
+	}
+
+	if len(connected) != 1 || connected[0] != "client-1" {
+		t.Errorf("OnConnect calls = %v, want [client-1]", connected)
+	}
+
+	if len(closed) != 1 || closed[0] != "client-1" {
+		t.Errorf("OnClose calls = %v, want [client-1]", closed)
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// defaultMaxBatch bounds how many queued commands a pipe sends in one write
+// unless auto-pipelining says otherwise.
+const defaultMaxBatch = 128
+
+// pipe keeps many commands in flight on a connection without request IDs.
+// A writer goroutine sends queued commands in order, whatever queued up
+// meanwhile in a single write, and a reader goroutine completes their
+// futures in the same order, which is the order the server answers in.
+type pipe struct {
+	clientWire *ClientWire
+	recorder   *Recorder
+	closeWire  func(*ClientWire)
+	hooks      *atomic.Pointer[hookChain]
+	pipelining pipelining
+	// full wakes a lingering writer once a batch is complete, stopped once
+	// the pipe stopped.
+	full    chan struct{}
+	stopped chan struct{}
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// queue waits for the writer, inflight for the reader.
+	queue    []*Future
+	inflight []*Future
+	// err is why the pipe stopped, after which no command is accepted.
+	err *wire.WireError
+}
+
+func newPipe(clientWire *ClientWire, recorder *Recorder, closeWire func(*ClientWire), hooks *atomic.Pointer[hookChain], pipelining pipelining) *pipe {
+	if pipelining.maxBatch == 0 {
+		pipelining.maxBatch = defaultMaxBatch
+	}
+
+	p := &pipe{
+		clientWire: clientWire,
+		recorder:   recorder,
+		closeWire:  closeWire,
+		hooks:      hooks,
+		pipelining: pipelining,
+		full:       make(chan struct{}, 1),
+		stopped:    make(chan struct{}),
+	}
+	p.cond = sync.NewCond(&p.mu)
+
+	go p.write()
+	go p.read()
+
+	return p
+}
+
+// enqueue queues f for the writer and reports false once the pipe stopped.
+func (p *pipe) enqueue(f *Future) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return false
+	}
+
+	p.queue = append(p.queue, f)
+	p.cond.Broadcast()
+
+	if len(p.queue) >= p.pipelining.maxBatch {
+		select {
+		case p.full <- struct{}{}:
+		default:
+		}
+	}
+
+	return true
+}
+
+func (p *pipe) write() {
+	for {
+		p.mu.Lock()
+		for len(p.queue) == 0 && p.err == nil {
+			p.cond.Wait()
+		}
+		if p.err == nil && len(p.queue) < p.pipelining.maxBatch && p.pipelining.window > 0 {
+			p.linger()
+		}
+		if p.err != nil {
+			p.mu.Unlock()
+			return
+		}
+
+		n := min(len(p.queue), p.pipelining.maxBatch)
+		batch := p.queue[:n:n]
+		p.queue = p.queue[n:]
+		// The reader expects results in the order the commands go out, so
+		// they are handed over before they are sent.
+		p.inflight = append(p.inflight, batch...)
+		p.cond.Broadcast()
+		p.mu.Unlock()
+
+		cmds := make([]*wire.Command, len(batch))
+		for i, f := range batch {
+			cmds[i] = f.cmd
+		}
+
+		// A batch carries commands of many callers, none of which may cancel
+		// it for the others.
+		ctx := context.WithoutCancel(batch[0].ctx)
+		if err := sendBatch(ctx, p.clientWire, p.hooks.Load().hooks, cmds); err != nil {
+			p.fail(err, sendFailure(err))
+			return
+		}
+	}
+}
+
+// linger gives other commands the pipelining window to join the queued ones
+// before they are sent. Callers hold mu, which is released meanwhile.
+func (p *pipe) linger() {
+	// A batch that completed before is already on its way.
+	select {
+	case <-p.full:
+	default:
+	}
+	p.mu.Unlock()
+
+	timer := time.NewTimer(p.pipelining.window)
+	select {
+	case <-timer.C:
+	case <-p.full:
+	case <-p.stopped:
+	}
+	timer.Stop()
+
+	p.mu.Lock()
+}
+
+func (p *pipe) read() {
+	for {
+		p.mu.Lock()
+		for len(p.inflight) == 0 && p.err == nil {
+			p.cond.Wait()
+		}
+		if p.err != nil {
+			p.mu.Unlock()
+			return
+		}
+
+		f := p.inflight[0]
+		p.inflight = p.inflight[1:]
+		p.mu.Unlock()
+
+		res, err := p.clientWire.Receive()
+		if err != nil {
+			f.fail(err, receiveFailure(err))
+			p.fail(err, receiveFailure(err))
+			return
+		}
+
+		// The server executed the command even when nobody waits for it
+		// anymore.
+		if p.recorder != nil {
+			p.recorder.record(f.cmd, res)
+		}
+		f.complete(res)
+	}
+}
+
+// fail stops the pipe, completes every future still queued or in flight
+// with res and closes the connection.
+func (p *pipe) fail(err *wire.WireError, res *wire.Result) {
+	p.mu.Lock()
+	if p.err != nil {
+		p.mu.Unlock()
+		return
+	}
+
+	p.err = err
+	pending := append(p.inflight, p.queue...)
+	p.inflight, p.queue = nil, nil
+	p.cond.Broadcast()
+	close(p.stopped)
+	p.mu.Unlock()
+
+	p.closeWire(p.clientWire)
+	for _, f := range pending {
+		f.fail(err, res)
+	}
+}
+
+// broken reports whether the pipe stopped.
+func (p *pipe) broken() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.err != nil
+}
+
+// sendPipelined queues the command of f on the client's pipe.
+func (c *Client) sendPipelined(f *Future) {
+	// A pipe that broke after asyncPipe returned it is replaced once.
+	for range 2 {
+		p, err := c.asyncPipe()
+		if err != nil {
+			f.fail(err, sendFailure(err))
+			return
+		}
+
+		if res := oversized(f.cmd, p.clientWire.caps); res != nil {
+			f.complete(res)
+			return
+		}
+
+		if p.enqueue(f) {
+			return
+		}
+	}
+
+	err := &wire.WireError{Kind: wire.Terminated, Cause: errors.New("async connection keeps failing")}
+	f.fail(err, sendFailure(err))
+}
+
+// firePipelined sends cmd through the client's pipe together with the
+// commands of concurrent callers and waits for its result.
+func (c *Client) firePipelined(ctx context.Context, cmd *wire.Command) (*wire.Result, *wire.WireError) {
+	f := newFuture(ctx, cmd)
+	c.sendPipelined(f)
+
+	return f.wait()
+}
+
+// asyncPipe returns the pipe FireAsync and auto-pipelined Fire send through, connecting a new one
+// when there is none yet or the last one broke.
+func (c *Client) asyncPipe() (*pipe, *wire.WireError) {
+	c.asyncMu.Lock()
+	defer c.asyncMu.Unlock()
+
+	if c.closed() {
+		return nil, &wire.WireError{Kind: wire.Terminated, Cause: errors.New("client is closed")}
+	}
+
+	if c.async != nil && !c.async.broken() {
+		return c.async, nil
+	}
+
+	var failed *ClientWire
+	if c.async != nil {
+		failed = c.async.clientWire
+	}
+
+	clientWire, err := c.openWire(failed)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.handshake(clientWire, "command", false); err != nil {
+		c.closeWire(clientWire)
+		return nil, err
+	}
+
+	c.async = newPipe(clientWire, c.recorder, c.closeWire, &c.hooks, c.pipelining)
+
+	return c.async, nil
+}
+
+// closeAsync stops the pipe, failing the commands still waiting on it.
+func (c *Client) closeAsync() {
+	c.asyncMu.Lock()
+	p := c.async
+	c.asyncMu.Unlock()
+
+	if p != nil {
+		err := &wire.WireError{Kind: wire.Terminated, Cause: errors.New("client is closed")}
+		p.fail(err, receiveFailure(err))
+	}
+}
@@ -0,0 +1,133 @@
+package dicedb_test
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/server"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// writeCountingConn counts the writes made to the connection.
+type writeCountingConn struct {
+	net.Conn
+	writes *atomic.Int64
+}
+
+func (c writeCountingConn) Write(p []byte) (int, error) {
+	c.writes.Add(1)
+	return c.Conn.Write(p)
+}
+
+func TestAutoPipeliningCoalescesConcurrentFires(t *testing.T) {
+	// arrange
+	_, addr := startServer(t, server.HandlerFunc(echo))
+
+	var writes atomic.Int64
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, network, addr)
+		return writeCountingConn{Conn: conn, writes: &writes}, err
+	}
+
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithDialer(dial), dicedb.WithAutoPipelining(50*time.Millisecond, 64))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// act
+	const callers = 32
+	before := writes.Load()
+	results := make([]*wire.Result, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{strconv.Itoa(i)}})
+		}()
+	}
+	wg.Wait()
+
+	// assert
+	for i, res := range results {
+		if got := res.GetECHORes().GetMessage(); got != strconv.Itoa(i) {
+			t.Errorf("Fire() #%d = %q, want its own result", i, got)
+		}
+	}
+
+	// The pipelined connection's handshake and a few batches.
+	if n := writes.Load() - before; n >= callers/2 {
+		t.Errorf("%d concurrent commands took %d writes, want them coalesced", callers, n)
+	}
+}
+
+// fireUntilOK fires cmd up to three times, since a command the lost
+// connection may have carried is failed rather than resent.
+func fireUntilOK(client *dicedb.Client, cmd *wire.Command) *wire.Result {
+	var res *wire.Result
+	for attempt := 0; attempt < 3; attempt++ {
+		if res = client.Fire(cmd); res.Status == wire.Status_OK {
+			break
+		}
+	}
+
+	return res
+}
+
+func TestAutoPipelinedConnectionIsRestored(t *testing.T) {
+	// arrange
+	_, addr := startServer(t, server.HandlerFunc(echo))
+
+	tracker := &connTracker{}
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithDialer(tracker.dial), dicedb.WithAutoPipelining(time.Millisecond, 64))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	if res := client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{"before"}}); res.Status != wire.Status_OK {
+		t.Fatalf("Fire() = %v, want OK", res)
+	}
+
+	// act
+	tracker.dropAll()
+	res := fireUntilOK(client, &wire.Command{Cmd: "ECHO", Args: []string{"after"}})
+
+	// assert
+	if got := res.GetECHORes().GetMessage(); got != "after" {
+		t.Errorf("Fire() after the connection dropped = %v, want after", res)
+	}
+}
+
+func TestAutoPipelinedConnectionFailsOver(t *testing.T) {
+	// arrange
+	primary, primaryAddr := startServer(t, answeredBy("primary"))
+	_, standbyAddr := startServer(t, answeredBy("standby"))
+
+	client, err := dicedb.NewClient(primaryAddr.IP.String(), primaryAddr.Port, dicedb.WithSeeds(standbyAddr.String()), dicedb.WithAutoPipelining(time.Millisecond, 64))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	if got := client.Fire(&wire.Command{Cmd: "ECHO"}).GetECHORes().GetMessage(); got != "primary" {
+		t.Fatalf("Fire() answered by %q, want primary", got)
+	}
+
+	// act
+	primary.Close()
+	res := fireUntilOK(client, &wire.Command{Cmd: "ECHO"})
+
+	// assert
+	if got := res.GetECHORes().GetMessage(); got != "standby" {
+		t.Errorf("Fire() after the primary went away = %v, want the standby's answer", res)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/sevenDatabase/SevenDB-go/wal"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Recorder appends every successfully executed mutating command to a WAL as
+// an ELEMENT_TYPE_COMMAND element holding the marshaled wire.Command, the
+// same layout wal.Replay consumes.
+type Recorder struct {
+	writer     *wal.Writer
+	sampleRate float64
+	keyFilter  func(key string) bool
+}
+
+type recorderOption func(*Recorder)
+
+// WithSampleRate records only the given fraction of mutating commands, from
+// 0 (none) to 1 (all, the default).
+func WithSampleRate(rate float64) recorderOption {
+	return func(r *Recorder) {
+		r.sampleRate = rate
+	}
+}
+
+// WithKeyFilter records only commands with at least one key accepted by
+// filter. Commands without keys, like FLUSHDB, are always recorded.
+func WithKeyFilter(filter func(key string) bool) recorderOption {
+	return func(r *Recorder) {
+		r.keyFilter = filter
+	}
+}
+
+// NewRecorder returns a recorder appending to w. The caller still owns w and
+// closes it after the clients using the recorder.
+func NewRecorder(w *wal.Writer, opts ...recorderOption) *Recorder {
+	r := &Recorder{
+		writer:     w,
+		sampleRate: 1,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// WithRecorder records the client's mutating commands with r.
+func WithRecorder(r *Recorder) option {
+	return func(c *Client) {
+		c.recorder = r
+	}
+}
+
+// nonMutating lists the commands that neither read nor modify the keyspace.
+var nonMutating = map[string]bool{
+	"PING":      true,
+	"ECHO":      true,
+	"HANDSHAKE": true,
+	"UNWATCH":   true,
+}
+
+func isMutating(cmd *wire.Command) bool {
+	name := strings.ToUpper(cmd.Cmd)
+	spec, ok := commandTable[name]
+
+	return ok && !spec.readOnly && !nonMutating[name] && !strings.HasSuffix(name, "WATCH")
+}
+
+// record appends cmd when it mutated the keyspace and passes sampling and
+// the key filter. Failing to record never fails the command itself.
+func (r *Recorder) record(cmd *wire.Command, res *wire.Result) {
+	if res.Status != wire.Status_OK || !isMutating(cmd) || !r.sampled() || !r.accepts(cmd) {
+		return
+	}
+
+	payload, err := proto.Marshal(cmd)
+	if err != nil {
+		slog.Warn("failed to marshal recorded command", "cmd", cmd.Cmd, "error", err)
+		return
+	}
+
+	if _, err := r.writer.Append(&wal.Element{
+		ElementType: wal.ElementType_ELEMENT_TYPE_COMMAND,
+		Payload:     payload,
+	}); err != nil {
+		slog.Warn("failed to record command", "cmd", cmd.Cmd, "error", err)
+	}
+}
+
+func (r *Recorder) sampled() bool {
+	return r.sampleRate >= 1 || rand.Float64() < r.sampleRate
+}
+
+func (r *Recorder) accepts(cmd *wire.Command) bool {
+	if r.keyFilter == nil {
+		return true
+	}
+
+	keys := commandKeys(cmd)
+	if len(keys) == 0 {
+		return true
+	}
+
+	for _, i := range keys {
+		if r.keyFilter(cmd.Args[i]) {
+			return true
+		}
+	}
+
+	return false
+}
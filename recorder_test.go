@@ -0,0 +1,143 @@
+package dicedb_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/sevendbmock"
+	"github.com/sevenDatabase/SevenDB-go/wal"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+func recordedCommands(t *testing.T, dir string) []string {
+	t.Helper()
+
+	r, err := wal.NewReader(dir, 0)
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	defer r.Close()
+
+	var cmds []string
+	for {
+		e, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return cmds
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+
+		cmd := &wire.Command{}
+		if err := proto.Unmarshal(e.Payload, cmd); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		cmds = append(cmds, cmd.Cmd+" "+strings.Join(cmd.Args, " "))
+	}
+}
+
+func TestRecorderRecordsSuccessfulWrites(t *testing.T) {
+	// arrange
+	srv, err := sevendbmock.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer srv.Close()
+
+	ok := &wire.Result{Status: wire.Status_OK, Message: "OK"}
+	srv.Expect("SET").WithArgs("a", "1").Return(ok)
+	srv.Expect("SET").WithArgs("b", "2").Return(&wire.Result{Status: wire.Status_ERR, Message: "rejected"})
+	srv.Expect("GET").Return(getResult("1"))
+	srv.Expect("DEL").Return(ok)
+
+	dir := t.TempDir()
+	w, err := wal.OpenWriter(dir, wal.Options{SyncPolicy: wal.SyncNever})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+
+	client, err := dicedb.NewClient(srv.Host(), srv.Port(), dicedb.WithRecorder(dicedb.NewRecorder(w)))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	// act
+	client.Fire(&wire.Command{Cmd: "SET", Args: []string{"a", "1"}})
+	client.Fire(&wire.Command{Cmd: "SET", Args: []string{"b", "2"}})
+	client.Fire(&wire.Command{Cmd: "GET", Args: []string{"a"}})
+	client.Fire(&wire.Command{Cmd: "DEL", Args: []string{"a"}})
+	client.Close()
+	w.Close()
+
+	// assert
+	if got := strings.Join(recordedCommands(t, dir), ","); got != "SET a 1,DEL a" {
+		t.Errorf("recorded %q, want %q", got, "SET a 1,DEL a")
+	}
+}
+
+func TestRecorderFiltering(t *testing.T) {
+	tests := []struct {
+		name     string
+		recorder func(w *wal.Writer) *dicedb.Recorder
+		want     string
+	}{
+		{
+			name: "key filter",
+			recorder: func(w *wal.Writer) *dicedb.Recorder {
+				return dicedb.NewRecorder(w, dicedb.WithKeyFilter(func(key string) bool {
+					return strings.HasPrefix(key, "user:")
+				}))
+			},
+			want: "SET user:1 v,FLUSHDB ",
+		},
+		{
+			name: "zero sample rate",
+			recorder: func(w *wal.Writer) *dicedb.Recorder {
+				return dicedb.NewRecorder(w, dicedb.WithSampleRate(0))
+			},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			srv, err := sevendbmock.NewServer()
+			if err != nil {
+				t.Fatalf("NewServer() error = %v", err)
+			}
+			defer srv.Close()
+
+			ok := &wire.Result{Status: wire.Status_OK, Message: "OK"}
+			srv.Expect("SET").Times(2).Return(ok)
+			srv.Expect("FLUSHDB").Return(ok)
+
+			dir := t.TempDir()
+			w, err := wal.OpenWriter(dir, wal.Options{SyncPolicy: wal.SyncNever})
+			if err != nil {
+				t.Fatalf("OpenWriter() error = %v", err)
+			}
+
+			client, err := dicedb.NewClient(srv.Host(), srv.Port(), dicedb.WithRecorder(tt.recorder(w)))
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			// act
+			client.Fire(&wire.Command{Cmd: "SET", Args: []string{"user:1", "v"}})
+			client.Fire(&wire.Command{Cmd: "SET", Args: []string{"cache:1", "v"}})
+			client.Fire(&wire.Command{Cmd: "FLUSHDB"})
+			client.Close()
+			w.Close()
+
+			// assert
+			if got := strings.Join(recordedCommands(t, dir), ","); got != tt.want {
+				t.Errorf("recorded %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
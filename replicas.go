@@ -0,0 +1,264 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+type ReadSelection int
+
+const (
+	SelectRoundRobin ReadSelection = iota
+	SelectLeastLatency
+)
+
+// latencyDecay weighs the newest sample in a replica's moving latency average.
+const latencyDecay = 0.2
+
+// replicaRetryInterval is how long a replica whose connection failed is
+// skipped before a read tries it again.
+const replicaRetryInterval = time.Second
+
+type replica struct {
+	endpoint Endpoint
+	// primary is the client the replica serves reads for.
+	primary *Client
+
+	mu sync.Mutex
+	// client is nil until the replica was connected, connecting is set
+	// while that is attempted in the background.
+	client     *Client
+	connecting bool
+	ewma       time.Duration
+	// retryAt is set while the replica is down, to when it is tried again.
+	retryAt time.Time
+}
+
+type replicaSet struct {
+	endpoints []Endpoint
+	selection ReadSelection
+	replicas  []*replica
+	next      atomic.Uint64
+}
+
+// WithReplicas adds read replicas. Read-only commands are routed to them and
+// everything else goes to the primary passed to NewClient. A replica that
+// cannot be reached, at NewClient or later, is skipped until it is back.
+func WithReplicas(eps ...Endpoint) option {
+	return func(c *Client) {
+		c.replicas.endpoints = append(c.replicas.endpoints, eps...)
+	}
+}
+
+// WithReadSelection sets how a read picks among the healthy replicas,
+// round-robin by default.
+func WithReadSelection(selection ReadSelection) option {
+	return func(c *Client) {
+		c.replicas.selection = selection
+	}
+}
+
+// FirePrimary fires cmd on the primary even when it is read-only, for reads
+// that must observe the caller's own writes.
+func (c *Client) FirePrimary(cmd *wire.Command) *wire.Result {
+	return c.hooks.Load().primary(context.Background(), cmd)
+}
+
+// connectReplicas opens a client per replica endpoint. A replica that cannot
+// be connected is added as down and connected again once a read picks it.
+func (c *Client) connectReplicas() {
+	for _, ep := range c.replicas.endpoints {
+		r := &replica{endpoint: ep, primary: c}
+
+		client, err := c.newReplicaClient(ep, nil)
+		if err != nil {
+			slog.Warn("replica is down", "endpoint", ep, "error", err)
+			r.retryAt = time.Now().Add(replicaRetryInterval)
+		}
+		r.client = client
+
+		c.replicas.replicas = append(c.replicas.replicas, r)
+	}
+}
+
+// newReplicaClient opens a client to a replica, sharing the primary's ID,
+// dialer, socket options, wire settings and hooks.
+func (c *Client) newReplicaClient(ep Endpoint, hooks []Hook) (*Client, error) {
+	client, err := NewClient(ep.Host, ep.Port, WithID(c.id), func(r *Client) {
+		r.dialer = c.dialer
+		r.sockOpts = c.sockOpts
+		r.onConnect = c.onConnect
+		r.onClose = c.onClose
+		r.frameTimeout = c.frameTimeout
+		r.compression = c.compression
+		r.checksums = c.checksums
+		r.maxResponseSize = c.maxResponseSize
+		r.multiplex = c.multiplex
+		r.pipelining = c.pipelining
+		r.hooks.Store(r.newHookChain(hooks))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to replica %s: %w", ep, err)
+	}
+
+	return client, nil
+}
+
+// connectReplica connects r, which was down, giving it the hooks added
+// meanwhile.
+func (c *Client) connectReplica(r *replica) {
+	c.hookMu.Lock()
+	hooks := c.hooks.Load().hooks
+	c.hookMu.Unlock()
+
+	client, err := c.newReplicaClient(r.endpoint, hooks)
+
+	// Holding hookMu, AddHook either already ran and its hook is applied
+	// here, or runs later and finds the client.
+	c.hookMu.Lock()
+	defer c.hookMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.connecting = false
+	if err != nil {
+		slog.Warn("replica is still down", "endpoint", r.endpoint, "error", err)
+		r.retryAt = time.Now().Add(replicaRetryInterval)
+		return
+	}
+
+	// closeReplicas may have passed r already once the primary is closed.
+	if c.closed() {
+		client.Close()
+		return
+	}
+
+	for _, hook := range c.hooks.Load().hooks[len(hooks):] {
+		client.AddHook(hook)
+	}
+	r.client = client
+	r.retryAt = time.Time{}
+}
+
+func (c *Client) closeReplicas() {
+	for _, r := range c.replicas.replicas {
+		if client := r.connected(); client != nil {
+			client.Close()
+		}
+	}
+}
+
+// pick returns a replica that is up, or due to be tried again, and nil when
+// every replica is down.
+func (rs *replicaSet) pick() *replica {
+	now := time.Now()
+
+	if rs.selection == SelectLeastLatency {
+		var best *replica
+		for _, r := range rs.replicas {
+			if r.available(now) && (best == nil || r.latency() < best.latency()) {
+				best = r
+			}
+		}
+
+		return best
+	}
+
+	start := rs.next.Add(1) - 1
+	for i := range uint64(len(rs.replicas)) {
+		if r := rs.replicas[(start+i)%uint64(len(rs.replicas))]; r.available(now) {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// fire sends a read to the replica and reports false when its connection
+// failed, in which case the replica is skipped for a while. It bypasses the
+// replica client's process hooks, the primary's already ran.
+func (r *replica) fire(ctx context.Context, cmd *wire.Command) (*wire.Result, bool) {
+	start := time.Now()
+	client := r.connect(start)
+	if client == nil {
+		return nil, false
+	}
+
+	resp, err := client.fireChecked(ctx, cmd, client.mainWire, client.restoreMainWire)
+	if err != nil {
+		slog.Warn("replica is down", "endpoint", client.ActiveEndpoint(), "error", err)
+		r.down(start)
+		return resp, false
+	}
+
+	r.observe(time.Since(start))
+
+	return resp, true
+}
+
+// connect returns the replica's client, or nil while it is not connected,
+// in which case it is connected in the background and skipped meanwhile.
+func (r *replica) connect(now time.Time) *Client {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.client == nil && !r.connecting {
+		r.connecting = true
+		r.retryAt = now.Add(replicaRetryInterval)
+		go r.primary.connectReplica(r)
+	}
+
+	return r.client
+}
+
+func (r *replica) connected() *Client {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.client
+}
+
+func (r *replica) available(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.retryAt.IsZero() || !now.Before(r.retryAt)
+}
+
+func (r *replica) down(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retryAt = now.Add(replicaRetryInterval)
+}
+
+// observe records the latency of a successful read, which also brings a
+// replica that was down back up.
+func (r *replica) observe(sample time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retryAt = time.Time{}
+	if r.ewma == 0 {
+		r.ewma = sample
+		return
+	}
+
+	r.ewma = time.Duration(latencyDecay*float64(sample) + (1-latencyDecay)*float64(r.ewma))
+}
+
+func (r *replica) latency() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.ewma
+}
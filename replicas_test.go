@@ -0,0 +1,204 @@
+package dicedb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/sevendbmock"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func getResult(value string) *wire.Result {
+	return &wire.Result{
+		Status:   wire.Status_OK,
+		Response: &wire.Result_GETRes{GETRes: &wire.GETRes{Value: value}},
+	}
+}
+
+func TestReadWriteSplitting(t *testing.T) {
+	// arrange
+	primary, err := sevendbmock.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer primary.Close()
+
+	replicas := make([]*sevendbmock.Server, 2)
+	endpoints := make([]dicedb.Endpoint, len(replicas))
+	for i := range replicas {
+		replicas[i], err = sevendbmock.NewServer()
+		if err != nil {
+			t.Fatalf("NewServer() error = %v", err)
+		}
+		defer replicas[i].Close()
+
+		endpoints[i] = dicedb.Endpoint{Host: replicas[i].Host(), Port: replicas[i].Port()}
+	}
+
+	primary.Expect("SET").Return(&wire.Result{Status: wire.Status_OK, Message: "OK"})
+	primary.Expect("GET").Return(getResult("primary"))
+	replicas[0].Expect("GET").Return(getResult("replica-0"))
+	replicas[1].Expect("GET").Return(getResult("replica-1"))
+
+	client, err := dicedb.NewClient(primary.Host(), primary.Port(), dicedb.WithReplicas(endpoints...))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// act
+	set := client.Fire(&wire.Command{Cmd: "SET", Args: []string{"k", "v"}})
+	first := client.Fire(&wire.Command{Cmd: "GET", Args: []string{"k"}})
+	second := client.Fire(&wire.Command{Cmd: "GET", Args: []string{"k"}})
+	forced := client.FirePrimary(&wire.Command{Cmd: "GET", Args: []string{"k"}})
+
+	// assert
+	if set.Status != wire.Status_OK {
+		t.Errorf("Fire() SET = %v, want OK from primary", set)
+	}
+
+	if got := first.GetGETRes().GetValue() + "," + second.GetGETRes().GetValue(); got != "replica-0,replica-1" {
+		t.Errorf("round-robin reads = %s, want replica-0,replica-1", got)
+	}
+
+	if got := forced.GetGETRes().GetValue(); got != "primary" {
+		t.Errorf("FirePrimary() GET = %s, want primary", got)
+	}
+
+	for _, srv := range append(replicas, primary) {
+		if err := srv.ExpectationsWereMet(); err != nil {
+			t.Errorf("ExpectationsWereMet() error = %v", err)
+		}
+	}
+}
+
+func TestReadsSurviveDeadReplicas(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection dicedb.ReadSelection
+		kill      int
+		wantFrom  string
+	}{
+		{name: "round-robin with one replica down", selection: dicedb.SelectRoundRobin, kill: 1, wantFrom: "replica-1"},
+		{name: "least-latency with one replica down", selection: dicedb.SelectLeastLatency, kill: 1, wantFrom: "replica-1"},
+		{name: "round-robin with every replica down", selection: dicedb.SelectRoundRobin, kill: 2, wantFrom: "primary"},
+		{name: "least-latency with every replica down", selection: dicedb.SelectLeastLatency, kill: 2, wantFrom: "primary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			const reads = 20
+
+			primary, err := sevendbmock.NewServer()
+			if err != nil {
+				t.Fatalf("NewServer() error = %v", err)
+			}
+			defer primary.Close()
+			primary.Expect("GET").Times(reads).Return(getResult("primary"))
+
+			replicas := make([]*sevendbmock.Server, 2)
+			endpoints := make([]dicedb.Endpoint, len(replicas))
+			for i := range replicas {
+				replicas[i], err = sevendbmock.NewServer()
+				if err != nil {
+					t.Fatalf("NewServer() error = %v", err)
+				}
+				defer replicas[i].Close()
+				replicas[i].Expect("GET").Times(reads).Return(getResult(fmt.Sprintf("replica-%d", i)))
+
+				endpoints[i] = dicedb.Endpoint{Host: replicas[i].Host(), Port: replicas[i].Port()}
+			}
+
+			client, err := dicedb.NewClient(primary.Host(), primary.Port(),
+				dicedb.WithReplicas(endpoints...), dicedb.WithReadSelection(tt.selection))
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			defer client.Close()
+
+			for _, r := range replicas[:tt.kill] {
+				r.Close()
+			}
+
+			// act
+			var results []*wire.Result
+			for range reads {
+				results = append(results, client.Fire(&wire.Command{Cmd: "GET", Args: []string{"k"}}))
+			}
+
+			// assert
+			for i, res := range results {
+				if res.Status != wire.Status_OK {
+					t.Fatalf("read %d = %v, want it served despite the dead replicas", i, res)
+				}
+			}
+
+			if got := results[len(results)-1].GetGETRes().GetValue(); got != tt.wantFrom {
+				t.Errorf("last read served by %s, want %s", got, tt.wantFrom)
+			}
+		})
+	}
+}
+
+func TestReplicaDownAtStartupIsConnectedLater(t *testing.T) {
+	// arrange
+	_, primaryAddr := startServer(t, answeredBy("primary"))
+	_, upAddr := startServer(t, answeredBy("replica-up"))
+	_, lateAddr := startServer(t, answeredBy("replica-late"))
+
+	var lateIsUp atomic.Bool
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == lateAddr.String() && !lateIsUp.Load() {
+			return nil, errors.New("replica is not up yet")
+		}
+
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+
+	client, err := dicedb.NewClient(primaryAddr.IP.String(), primaryAddr.Port, dicedb.WithDialer(dial),
+		dicedb.WithReplicas(
+			dicedb.Endpoint{Host: upAddr.IP.String(), Port: upAddr.Port},
+			dicedb.Endpoint{Host: lateAddr.IP.String(), Port: lateAddr.Port},
+		))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	get := func() string {
+		return client.Fire(&wire.Command{Cmd: "GET", Args: []string{"k"}}).GetECHORes().GetMessage()
+	}
+
+	// act
+	var before []string
+	for range 4 {
+		before = append(before, get())
+	}
+
+	lateIsUp.Store(true)
+	var after string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if after = get(); after == "replica-late" {
+			break
+		}
+	}
+
+	// assert
+	for i, got := range before {
+		if got != "replica-up" {
+			t.Errorf("read %d served by %q while a replica was down, want replica-up", i, got)
+		}
+	}
+
+	if after != "replica-late" {
+		t.Errorf("reads never reached the replica that came up, last one served by %q", after)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// hashRing is a consistent-hash ring. Every node is placed on the ring at
+// vnodes points, so adding or removing a node only moves the keys between
+// its points and their predecessors.
+type hashRing struct {
+	vnodes int
+	points []uint64
+	owners map[uint64]string
+}
+
+func newHashRing(vnodes int) *hashRing {
+	return &hashRing{
+		vnodes: vnodes,
+		owners: make(map[uint64]string),
+	}
+}
+
+func (r *hashRing) add(node string) {
+	for i := 0; i < r.vnodes; i++ {
+		point := hashKey(node + "#" + strconv.Itoa(i))
+		if _, taken := r.owners[point]; taken {
+			continue
+		}
+
+		r.owners[point] = node
+		r.points = append(r.points, point)
+	}
+
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+}
+
+func (r *hashRing) remove(node string) {
+	points := r.points[:0]
+	for _, point := range r.points {
+		if r.owners[point] == node {
+			delete(r.owners, point)
+			continue
+		}
+
+		points = append(points, point)
+	}
+
+	r.points = points
+}
+
+func (r *hashRing) locate(key string) (string, bool) {
+	if len(r.points) == 0 {
+		return "", false
+	}
+
+	h := hashKey(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.owners[r.points[i]], true
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	// FNV alone clusters short keys that differ only in their last bytes,
+	// such as the "node#i" virtual node names; a 64-bit finalizer spreads them.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+
+	return x
+}
@@ -0,0 +1,84 @@
+package dicedb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func TestHashRingMinimalMovement(t *testing.T) {
+	// arrange
+	ring := newHashRing(defaultVirtualNodes)
+	for _, node := range []string{"a", "b", "c"} {
+		ring.add(node)
+	}
+
+	const keys = 10000
+	before := make(map[string]string, keys)
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		before[key], _ = ring.locate(key)
+	}
+
+	// act
+	ring.add("d")
+
+	// assert
+	moved := 0
+	for key, owner := range before {
+		now, _ := ring.locate(key)
+		if now == owner {
+			continue
+		}
+
+		moved++
+		if now != "d" {
+			t.Fatalf("key %s moved from %s to %s, want only moves to d", key, owner, now)
+		}
+	}
+
+	if moved < keys/8 || moved > keys*3/8 {
+		t.Errorf("moved %d of %d keys, want about a quarter", moved, keys)
+	}
+}
+
+func TestHashRingRemove(t *testing.T) {
+	// arrange
+	ring := newHashRing(defaultVirtualNodes)
+	ring.add("a")
+	ring.add("b")
+
+	// act
+	ring.remove("a")
+
+	// assert
+	for i := 0; i < 100; i++ {
+		if owner, _ := ring.locate(fmt.Sprintf("key:%d", i)); owner != "b" {
+			t.Fatalf("locate() = %s after removing a, want b", owner)
+		}
+	}
+}
+
+func TestCommandKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  *wire.Command
+		want []int
+	}{
+		{name: "first arg", cmd: &wire.Command{Cmd: "SET", Args: []string{"k", "v"}}, want: []int{0}},
+		{name: "every arg", cmd: &wire.Command{Cmd: "del", Args: []string{"a", "b", "c"}}, want: []int{0, 1, 2}},
+		{name: "no keys", cmd: &wire.Command{Cmd: "PING"}, want: nil},
+		{name: "missing key", cmd: &wire.Command{Cmd: "GET"}, want: nil},
+		{name: "unknown command", cmd: &wire.Command{Cmd: "NOPE", Args: []string{"k"}}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := commandKeys(tt.cmd)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("commandKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// Handler serves a single command and returns the result sent back to the
+// client. A nil result is reported to the client as an error.
+type Handler interface {
+	ServeCommand(ctx context.Context, cmd *wire.Command) *wire.Result
+}
+
+type HandlerFunc func(ctx context.Context, cmd *wire.Command) *wire.Result
+
+func (f HandlerFunc) ServeCommand(ctx context.Context, cmd *wire.Command) *wire.Result {
+	return f(ctx, cmd)
+}
+
+// Middleware wraps a handler, for example to log, authorize or time commands.
+type Middleware func(next Handler) Handler
+
+// Router dispatches commands to handlers by command name, case-insensitively.
+// Middleware registered with Use wraps every routed command, including the
+// not-found handler.
+type Router struct {
+	mu         sync.RWMutex
+	handlers   map[string]Handler
+	middleware []Middleware
+	notFound   Handler
+}
+
+func NewRouter() *Router {
+	return &Router{
+		handlers: make(map[string]Handler),
+		notFound: HandlerFunc(func(ctx context.Context, cmd *wire.Command) *wire.Result {
+			return errResult("unknown command '%s'", cmd.Cmd)
+		}),
+	}
+}
+
+func (r *Router) Handle(cmd string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[strings.ToUpper(cmd)] = h
+}
+
+func (r *Router) HandleFunc(cmd string, f func(ctx context.Context, cmd *wire.Command) *wire.Result) {
+	r.Handle(cmd, HandlerFunc(f))
+}
+
+// NotFound replaces the handler used for commands without a route.
+func (r *Router) NotFound(h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.notFound = h
+}
+
+// Use appends middleware. The first middleware registered is the outermost.
+func (r *Router) Use(mw ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.middleware = append(r.middleware, mw...)
+}
+
+func (r *Router) ServeCommand(ctx context.Context, cmd *wire.Command) *wire.Result {
+	r.mu.RLock()
+	h, ok := r.handlers[strings.ToUpper(cmd.Cmd)]
+	if !ok {
+		h = r.notFound
+	}
+
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		h = r.middleware[i](h)
+	}
+	r.mu.RUnlock()
+
+	return h.ServeCommand(ctx, cmd)
+}
+
+func errResult(format string, args ...any) *wire.Result {
+	return &wire.Result{
+		Status:  wire.Status_ERR,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
@@ -0,0 +1,503 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+// Package server runs the SevenDB protocol on top of dicedb.ServerWire: an
+// accept loop, one goroutine per connection, handshake handling, command
+// routing and graceful shutdown. It is meant for proxies and test doubles.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultMaxMsgSize  = 32 * 1024 * 1024 // 32 MB
+	defaultMaxInFlight = 128
+)
+
+var ErrServerClosed = errors.New("server: Server closed")
+
+type Server struct {
+	// Handler serves every command received after the handshake.
+	Handler Handler
+
+	// MaxMsgSize bounds incoming frames. Defaults to 32 MB.
+	MaxMsgSize int
+
+	// IdleTimeout closes connections that send no command for this long.
+	// Watch sessions only listen, so they are exempt. Zero disables it.
+	IdleTimeout time.Duration
+
+	// WriteTimeout bounds how long sending a single result may take, so a
+	// slow client cannot hold a connection goroutine. Zero disables it.
+	WriteTimeout time.Duration
+
+	// FrameTimeout bounds how long the rest of a command may take to arrive
+	// once its length prefix has. Zero disables it.
+	FrameTimeout time.Duration
+
+	// Compressors are the compressors offered to clients in the handshake.
+	// A client gets the first one it asked for that is listed here.
+	Compressors []dicedb.Compressor
+
+	// CompressionThreshold is the result size below which results are sent
+	// uncompressed. Zero means dicedb.DefaultCompressionThreshold.
+	CompressionThreshold int
+
+	// Checksums accepts clients offering a CRC32C trailer on every frame.
+	Checksums bool
+
+	// StreamChunkSize streams results larger than it in chunks of that size
+	// to clients that can read streams, so they are not bounded by the
+	// client's max response size. Zero means dicedb.DefaultStreamChunkSize,
+	// a negative size never streams.
+	StreamChunkSize int
+
+	// MaxInFlight bounds the commands served at once on a connection whose
+	// client negotiated request IDs. Zero means 128.
+	MaxInFlight int
+
+	// MemoryBudget caps the bytes of commands a connection holds at once.
+	// Once the commands being served fill it, the connection reads nothing
+	// more until some are answered, and a single command larger than the
+	// budget closes the connection. Zero allows MaxMsgSize per command.
+	MemoryBudget int
+
+	mu         sync.Mutex
+	listeners  map[net.Listener]struct{}
+	conns      map[*conn]struct{}
+	watchers   map[string]*Session
+	inShutdown atomic.Bool
+	wg         sync.WaitGroup
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+type conn struct {
+	server  *Server
+	netConn net.Conn
+	wire    *dicedb.ServerWire
+	session *Session
+	// inFlight counts the commands being served.
+	inFlight atomic.Int32
+	// handlers tracks the goroutines serving commands concurrently once the
+	// client negotiated request IDs, and slots bounds them.
+	handlers sync.WaitGroup
+	slots    chan struct{}
+	// memUsed is the size of the commands served concurrently, kept within
+	// the server's MemoryBudget.
+	memMu   sync.Mutex
+	memFree *sync.Cond
+	memUsed int
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener until it fails or the server is shut
+// down, in which case ErrServerClosed is returned.
+func (s *Server) Serve(listener net.Listener) error {
+	if !s.track(listener) {
+		listener.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(listener)
+
+	for {
+		netConn, err := listener.Accept()
+		if err != nil {
+			if s.inShutdown.Load() {
+				return ErrServerClosed
+			}
+
+			return err
+		}
+
+		c := &conn{
+			server:  s,
+			netConn: netConn,
+			wire:    dicedb.NewServerWireFromConn(s.maxMsgSize(), netConn),
+			slots:   make(chan struct{}, s.maxInFlight()),
+		}
+		c.memFree = sync.NewCond(&c.memMu)
+
+		if !s.trackConn(c) {
+			c.wire.Close()
+			return ErrServerClosed
+		}
+
+		s.wg.Add(1)
+		go c.serve()
+	}
+}
+
+// Shutdown stops accepting connections, closes idle ones and waits for the
+// commands in flight to be answered before closing the rest. If ctx expires
+// first the remaining connections are closed forcibly and ctx.Err() returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.inShutdown.Store(true)
+	s.closeListeners()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	// Idle connections are expired again on every tick, since one that was
+	// between commands may have set a new read deadline meanwhile.
+	for {
+		if s.closeIdleConns() == 0 {
+			s.wg.Wait()
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			s.Close()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close stops the server immediately, closing every connection.
+func (s *Server) Close() error {
+	s.inShutdown.Store(true)
+	s.closeListeners()
+	s.baseContext()
+	s.cancel()
+
+	s.mu.Lock()
+	for c := range s.conns {
+		c.wire.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return nil
+}
+
+// Watcher returns the watch session opened by the client with the given ID,
+// so handlers serving watch commands can push updates to it.
+func (s *Server) Watcher(clientID string) (*Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.watchers[clientID]
+	return session, ok
+}
+
+func (s *Server) streamChunkSize() int {
+	switch {
+	case s.StreamChunkSize > 0:
+		return s.StreamChunkSize
+	case s.StreamChunkSize < 0:
+		return 0
+	default:
+		return dicedb.DefaultStreamChunkSize
+	}
+}
+
+func (s *Server) maxInFlight() int {
+	if s.MaxInFlight > 0 {
+		return s.MaxInFlight
+	}
+
+	return defaultMaxInFlight
+}
+
+func (s *Server) maxMsgSize() int {
+	if s.MaxMsgSize > 0 {
+		return s.MaxMsgSize
+	}
+
+	return defaultMaxMsgSize
+}
+
+func (s *Server) baseContext() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+
+	return s.ctx
+}
+
+func (s *Server) track(listener net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inShutdown.Load() {
+		return false
+	}
+
+	if s.listeners == nil {
+		s.listeners = make(map[net.Listener]struct{})
+	}
+	s.listeners[listener] = struct{}{}
+
+	return true
+}
+
+func (s *Server) untrack(listener net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.listeners, listener)
+}
+
+func (s *Server) trackConn(c *conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inShutdown.Load() {
+		return false
+	}
+
+	if s.conns == nil {
+		s.conns = make(map[*conn]struct{})
+	}
+	s.conns[c] = struct{}{}
+
+	return true
+}
+
+func (s *Server) untrackConn(c *conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.conns, c)
+	if c.session != nil && c.session.Mode == ModeWatch && s.watchers[c.session.ID] == c.session {
+		delete(s.watchers, c.session.ID)
+	}
+}
+
+func (s *Server) closeListeners() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for listener := range s.listeners {
+		listener.Close()
+	}
+}
+
+// closeIdleConns unblocks connections waiting for a command and returns how
+// many connections are still open.
+func (s *Server) closeIdleConns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.conns {
+		if c.inFlight.Load() != 0 {
+			continue
+		}
+
+		// Expiring the read deadline instead of closing lets a command that
+		// was already read be answered before the connection goes away.
+		c.netConn.SetReadDeadline(time.Now())
+	}
+
+	return len(s.conns)
+}
+
+func (s *Server) registerWatcher(session *Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.watchers == nil {
+		s.watchers = make(map[string]*Session)
+	}
+	s.watchers[session.ID] = session
+}
+
+func (c *conn) serve() {
+	s := c.server
+	defer s.wg.Done()
+	defer s.untrackConn(c)
+	defer c.wire.Close()
+
+	ctx := s.baseContext()
+
+	c.wire.SetIdleTimeout(s.IdleTimeout)
+	c.wire.SetFrameTimeout(s.FrameTimeout)
+	c.wire.SetFrameBudget(s.MemoryBudget)
+
+	// Commands served concurrently are answered before the wire closes.
+	defer c.handlers.Wait()
+
+	for {
+		id, cmd, err := c.wire.ReceiveWithID(ctx)
+		if err != nil {
+			if err.Kind != wire.Empty && !s.inShutdown.Load() {
+				slog.Debug("closing connection", "remote", c.netConn.RemoteAddr(), "error", err)
+			}
+			return
+		}
+
+		c.inFlight.Add(1)
+
+		// With request IDs the client does not wait for one result before
+		// sending the next command, so commands are served as they come and
+		// answered in whatever order they complete.
+		if c.session != nil && c.session.Capabilities.RequestIDs {
+			size := proto.Size(cmd)
+			c.reserve(size)
+			c.slots <- struct{}{}
+			c.handlers.Add(1)
+			go func() {
+				defer c.handlers.Done()
+				defer func() { <-c.slots }()
+				defer c.release(size)
+
+				if err := c.serveCommand(ctx, id, cmd); err != nil {
+					c.wire.Close()
+				}
+			}()
+
+			if s.inShutdown.Load() {
+				return
+			}
+			continue
+		}
+
+		if err := c.serveCommand(ctx, id, cmd); err != nil || s.inShutdown.Load() {
+			return
+		}
+	}
+}
+
+// reserve waits until a command of n bytes fits in the memory budget next to
+// the ones being served. A command is always admitted on its own, the wire
+// already rejected any larger than the budget.
+func (c *conn) reserve(n int) {
+	if c.server.MemoryBudget <= 0 {
+		return
+	}
+
+	c.memMu.Lock()
+	defer c.memMu.Unlock()
+
+	for c.memUsed > 0 && c.memUsed+n > c.server.MemoryBudget {
+		c.memFree.Wait()
+	}
+	c.memUsed += n
+}
+
+func (c *conn) release(n int) {
+	if c.server.MemoryBudget <= 0 {
+		return
+	}
+
+	c.memMu.Lock()
+	defer c.memMu.Unlock()
+
+	c.memUsed -= n
+	c.memFree.Broadcast()
+}
+
+func (c *conn) serveCommand(ctx context.Context, id uint32, cmd *wire.Command) *wire.WireError {
+	defer c.inFlight.Add(-1)
+
+	return c.send(ctx, id, c.dispatch(ctx, cmd))
+}
+
+func (c *conn) send(ctx context.Context, id uint32, res *wire.Result) *wire.WireError {
+	if c.server.WriteTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.server.WriteTimeout)
+		defer cancel()
+	}
+
+	if c.session != nil {
+		return c.session.send(ctx, id, res)
+	}
+
+	return c.wire.SendWithID(ctx, id, res)
+}
+
+func (c *conn) dispatch(ctx context.Context, cmd *wire.Command) (res *wire.Result) {
+	if strings.EqualFold(cmd.Cmd, "HANDSHAKE") {
+		return c.handshake(cmd)
+	}
+
+	if c.session == nil {
+		return errResult("handshake required before %s", cmd.Cmd)
+	}
+
+	if c.server.Handler == nil {
+		return errResult("no handler configured")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("handler panicked", "cmd", cmd.Cmd, "panic", r)
+			res = errResult("internal error serving %s", cmd.Cmd)
+		}
+	}()
+
+	res = c.server.Handler.ServeCommand(context.WithValue(ctx, sessionKey{}, c.session), cmd)
+	if res == nil {
+		return errResult("handler returned no result for %s", cmd.Cmd)
+	}
+
+	return res
+}
+
+func (c *conn) handshake(cmd *wire.Command) *wire.Result {
+	if c.session != nil {
+		return errResult("handshake already completed")
+	}
+
+	if len(cmd.Args) < 2 {
+		return errResult("HANDSHAKE expects at least 2 arguments, got %d", len(cmd.Args))
+	}
+
+	mode := Mode(cmd.Args[1])
+	if mode != ModeCommand && mode != ModeWatch {
+		return errResult("invalid handshake mode %q", cmd.Args[1])
+	}
+
+	caps, ack := c.wire.AcceptHandshake(cmd.Args[2:], dicedb.ServerCapabilities{
+		Compressors:          c.server.Compressors,
+		CompressionThreshold: c.server.CompressionThreshold,
+		MaxFrameSize:         c.server.maxMsgSize(),
+		Checksums:            c.server.Checksums,
+		StreamChunkSize:      c.server.streamChunkSize(),
+		RequestIDs:           true,
+	})
+
+	c.session = &Session{ID: cmd.Args[0], Mode: mode, Capabilities: caps, wire: c.wire}
+	if mode == ModeWatch {
+		c.wire.SetIdleTimeout(0)
+		c.server.registerWatcher(c.session)
+	}
+
+	message := fmt.Sprintf("handshake completed in %s mode", mode)
+	if len(ack) > 0 {
+		message += " " + strings.Join(ack, " ")
+	}
+
+	return &wire.Result{
+		Status:   wire.Status_OK,
+		Message:  message,
+		Response: &wire.Result_HANDSHAKERes{HANDSHAKERes: &wire.HANDSHAKERes{}},
+	}
+}
@@ -0,0 +1,447 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func start(t *testing.T, h Handler) (*Server, *net.TCPAddr) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	srv := &Server{Handler: h}
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+
+	return srv, listener.Addr().(*net.TCPAddr)
+}
+
+func echo(ctx context.Context, cmd *wire.Command) *wire.Result {
+	return &wire.Result{
+		Status:   wire.Status_OK,
+		Response: &wire.Result_ECHORes{ECHORes: &wire.ECHORes{Message: cmd.Args[0]}},
+	}
+}
+
+func TestRouterWithMiddleware(t *testing.T) {
+	// arrange
+	router := NewRouter()
+	router.HandleFunc("echo", echo)
+
+	var seen []string
+	router.Use(func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, cmd *wire.Command) *wire.Result {
+			session, _ := SessionFromContext(ctx)
+			seen = append(seen, session.ID+":"+cmd.Cmd)
+			return next.ServeCommand(ctx, cmd)
+		})
+	})
+
+	_, addr := start(t, router)
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithID("c1"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// act
+	echoed := client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{"hi"}})
+	unknown := client.Fire(&wire.Command{Cmd: "NOPE"})
+
+	// assert
+	if echoed.GetECHORes().GetMessage() != "hi" {
+		t.Errorf("Fire() ECHO = %v, want hi", echoed)
+	}
+
+	if unknown.Status != wire.Status_ERR {
+		t.Errorf("Fire() NOPE status = %v, want %v", unknown.Status, wire.Status_ERR)
+	}
+
+	if len(seen) != 2 || seen[0] != "c1:ECHO" || seen[1] != "c1:NOPE" {
+		t.Errorf("middleware saw %v, want [c1:ECHO c1:NOPE]", seen)
+	}
+}
+
+func TestHandshakeRequired(t *testing.T) {
+	// arrange
+	_, addr := start(t, HandlerFunc(echo))
+	cw, err := dicedb.NewClientWire(defaultMaxMsgSize, addr.IP.String(), addr.Port)
+	if err != nil {
+		t.Fatalf("NewClientWire() error = %v", err)
+	}
+	defer cw.Close()
+
+	// act
+	if err := cw.Send(&wire.Command{Cmd: "ECHO", Args: []string{"hi"}}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	resp, err := cw.Receive()
+
+	// assert
+	if err != nil || resp.Status != wire.Status_ERR {
+		t.Errorf("Receive() = %v, %v, want handshake error", resp, err)
+	}
+}
+
+func TestWatchSessionPush(t *testing.T) {
+	// arrange
+	srv, addr := start(t, HandlerFunc(echo))
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithID("w1"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	ch, err := client.WatchCh()
+	if err != nil {
+		t.Fatalf("WatchCh() error = %v", err)
+	}
+
+	// act
+	session, ok := srv.Watcher("w1")
+	if !ok {
+		t.Fatalf("Watcher() found no session for w1")
+	}
+	if err := session.Push(context.Background(), &wire.Result{Status: wire.Status_OK, Message: "update"}); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	// assert
+	select {
+	case resp := <-ch:
+		if resp.Message != "update" {
+			t.Errorf("watch received %v, want update", resp)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("watch received nothing")
+	}
+}
+
+func TestShutdownClosesConnectionsBetweenCommands(t *testing.T) {
+	// arrange
+	srv, addr := start(t, HandlerFunc(echo))
+
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	stop := make(chan struct{})
+	firing := make(chan struct{})
+	go func() {
+		defer close(firing)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{"busy"}})
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-firing
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// act
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Shutdown(context.Background())
+	}()
+
+	// assert
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Shutdown() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown() did not return while a client kept firing")
+	}
+}
+
+func TestShutdownDrainsInFlightCommands(t *testing.T) {
+	// arrange
+	started := make(chan struct{})
+	srv, addr := start(t, HandlerFunc(func(ctx context.Context, cmd *wire.Command) *wire.Result {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		return echo(ctx, cmd)
+	}))
+
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	results := make(chan *wire.Result, 1)
+	go func() {
+		results <- client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{"slow"}})
+	}()
+	<-started
+
+	// act
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err = srv.Shutdown(ctx)
+
+	// assert
+	if err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+
+	if resp := <-results; resp.GetECHORes().GetMessage() != "slow" {
+		t.Errorf("in-flight Fire() = %v, want slow", resp)
+	}
+
+	if _, err := net.Dial("tcp", addr.String()); err == nil {
+		t.Errorf("Dial() after Shutdown succeeded, want refused")
+	}
+}
+
+// countingConn counts the bytes read from the connection.
+type countingConn struct {
+	net.Conn
+	read *atomic.Int64
+}
+
+func (c countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func TestCompressionNegotiation(t *testing.T) {
+	flate, err := dicedb.NewFlateCompressor(-1)
+	if err != nil {
+		t.Fatalf("NewFlateCompressor() error = %v", err)
+	}
+	gzip, err := dicedb.NewGzipCompressor(-1)
+	if err != nil {
+		t.Fatalf("NewGzipCompressor() error = %v", err)
+	}
+
+	payload := strings.Repeat("compressible ", 1000)
+
+	tests := []struct {
+		name           string
+		serverSupports []dicedb.Compressor
+		wantCompressed bool
+	}{
+		{name: "server picks an offered compressor", serverSupports: []dicedb.Compressor{gzip, flate}, wantCompressed: true},
+		{name: "server without compression", wantCompressed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Listen() error = %v", err)
+			}
+			srv := &Server{Handler: HandlerFunc(echo), Compressors: tt.serverSupports}
+			go srv.Serve(listener)
+			defer srv.Close()
+			addr := listener.Addr().(*net.TCPAddr)
+
+			var read atomic.Int64
+			dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+				var d net.Dialer
+				conn, err := d.DialContext(ctx, network, addr)
+				return countingConn{Conn: conn, read: &read}, err
+			}
+
+			client, err := dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithDialer(dial), dicedb.WithCompression(64, flate))
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			defer client.Close()
+
+			// act
+			before := read.Load()
+			res := client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{payload}})
+			received := read.Load() - before
+
+			// assert
+			if res.GetECHORes().GetMessage() != payload {
+				t.Fatalf("Fire() ECHO = %.40v, want the payload back", res)
+			}
+
+			if compressed := received < int64(len(payload)); compressed != tt.wantCompressed {
+				t.Errorf("received %d bytes for a %d byte payload, want compressed = %v", received, len(payload), tt.wantCompressed)
+			}
+		})
+	}
+}
+
+func TestCapabilityNegotiation(t *testing.T) {
+	// arrange
+	var session *Session
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	srv := &Server{MaxMsgSize: 1024, Checksums: true, Handler: HandlerFunc(func(ctx context.Context, cmd *wire.Command) *wire.Result {
+		session, _ = SessionFromContext(ctx)
+		return echo(ctx, cmd)
+	})}
+	go srv.Serve(listener)
+	defer srv.Close()
+	addr := listener.Addr().(*net.TCPAddr)
+
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithChecksums())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// act
+	small := client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{"hi"}})
+	large := client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{strings.Repeat("x", 2048)}})
+	after := client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{"still there"}})
+
+	// assert
+	if got, want := client.Capabilities(), (dicedb.Capabilities{Version: dicedb.ProtocolVersion, MaxFrameSize: 1024, Checksums: true, Streaming: true}); got != want {
+		t.Errorf("client Capabilities() = %+v, want %+v", got, want)
+	}
+
+	if session == nil || session.Capabilities.Version != dicedb.ProtocolVersion || session.Capabilities.MaxFrameSize == 0 || !session.Capabilities.Checksums {
+		t.Errorf("session capabilities = %+v, want version %d with a max frame size and checksums", session, dicedb.ProtocolVersion)
+	}
+
+	if small.Status != wire.Status_OK || large.Status != wire.Status_ERR || after.Status != wire.Status_OK {
+		t.Errorf("Fire() statuses = %v, %v, %v, want the oversized command rejected locally", small.Status, large.Status, after.Status)
+	}
+}
+
+func TestStreamingLargeValues(t *testing.T) {
+	// arrange
+	value := strings.Repeat("large value ", 20000)
+	router := NewRouter()
+	router.HandleFunc("GET", func(ctx context.Context, cmd *wire.Command) *wire.Result {
+		if cmd.Args[0] != "big" {
+			return errResult("no such key %s", cmd.Args[0])
+		}
+		return &wire.Result{
+			Status:   wire.Status_OK,
+			Response: &wire.Result_GETRes{GETRes: &wire.GETRes{Value: value}},
+		}
+	})
+	router.HandleFunc("ECHO", echo)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	srv := &Server{Handler: router, StreamChunkSize: 4096}
+	go srv.Serve(listener)
+	defer srv.Close()
+	addr := listener.Addr().(*net.TCPAddr)
+
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithMaxResponseSize(64*1024))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// act
+	stream, streamErr := client.GetStream(context.Background(), "big")
+	var streamed []byte
+	var readErr error
+	if streamErr == nil {
+		streamed, readErr = io.ReadAll(stream)
+		stream.Close()
+	}
+	echoed := client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{strings.Repeat("e", 10000)}})
+	_, missingErr := client.GetStream(context.Background(), "missing")
+	fired := client.Fire(&wire.Command{Cmd: "GET", Args: []string{"big"}})
+	partial, _ := client.GetStream(context.Background(), "big")
+	partial.Read(make([]byte, 10))
+	partial.Close()
+	after := client.Fire(&wire.Command{Cmd: "ECHO", Args: []string{"hi"}})
+
+	// assert
+	if streamErr != nil || readErr != nil || string(streamed) != value {
+		t.Errorf("GetStream() read %d bytes, %v, %v, want the %d byte value", len(streamed), streamErr, readErr, len(value))
+	}
+
+	if echoed.GetECHORes().GetMessage() != strings.Repeat("e", 10000) {
+		t.Errorf("Fire() ECHO after the stream = %.40v, want the message back", echoed)
+	}
+
+	if missingErr == nil || !strings.Contains(missingErr.Error(), "no such key") {
+		t.Errorf("GetStream() of a missing key error = %v, want the server's error", missingErr)
+	}
+
+	if fired.Status != wire.Status_ERR {
+		t.Errorf("Fire() GET of a value over the max response size = %v, want an error", fired.Status)
+	}
+
+	if after.GetECHORes().GetMessage() != "hi" {
+		t.Errorf("Fire() after closing a stream early = %v, want the connection restored", after)
+	}
+}
+
+func TestMemoryBudgetBoundsConcurrentCommands(t *testing.T) {
+	// arrange
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	var active, peak atomic.Int32
+	srv := &Server{
+		Handler: HandlerFunc(func(ctx context.Context, cmd *wire.Command) *wire.Result {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(20 * time.Millisecond)
+			return echo(ctx, cmd)
+		}),
+		MemoryBudget: 1000,
+	}
+	go srv.Serve(listener)
+	defer srv.Close()
+	addr := listener.Addr().(*net.TCPAddr)
+
+	client, err := dicedb.NewClient(addr.IP.String(), addr.Port, dicedb.WithRequestIDs())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// act
+	payload := strings.Repeat("x", 600)
+	futures := make([]*dicedb.Future, 3)
+	for i := range futures {
+		futures[i] = client.FireAsync(context.Background(), &wire.Command{Cmd: "ECHO", Args: []string{payload}})
+	}
+
+	// assert
+	for i, f := range futures {
+		if got := f.Wait().GetECHORes().GetMessage(); got != payload {
+			t.Errorf("future %d got %d bytes, want %d", i, len(got), len(payload))
+		}
+	}
+
+	if peak.Load() != 1 {
+		t.Errorf("%d commands were served at once, want 1 within the budget", peak.Load())
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package server
+
+import (
+	"context"
+	"sync"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+type Mode string
+
+const (
+	ModeCommand Mode = "command"
+	ModeWatch   Mode = "watch"
+)
+
+// Session is the server side of a connection that completed its handshake.
+type Session struct {
+	ID   string
+	Mode Mode
+	// Capabilities is what the handshake negotiated with the client.
+	Capabilities dicedb.Capabilities
+
+	sendMu sync.Mutex
+	wire   *dicedb.ServerWire
+}
+
+// Push sends an unsolicited result to the client. It is meant for watch
+// sessions, whose clients only listen after the handshake.
+func (s *Session) Push(ctx context.Context, res *wire.Result) *wire.WireError {
+	return s.send(ctx, 0, res)
+}
+
+// send answers the command that carried the request ID id, see
+// dicedb.ServerWire.SendWithID.
+func (s *Session) send(ctx context.Context, id uint32, res *wire.Result) *wire.WireError {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	return s.wire.SendWithID(ctx, id, res)
+}
+
+type sessionKey struct{}
+
+// SessionFromContext returns the session of the connection a command arrived on.
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(sessionKey{}).(*Session)
+	return s, ok
+}
@@ -8,14 +8,27 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sevenDatabase/SevenDB-go/internal"
 	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
 )
 
 type ServerWire struct {
 	*internal.ProtobufTCPWire
+	conn        net.Conn
+	idleTimeout time.Duration
+	caps        atomic.Pointer[Capabilities]
+	pending     atomic.Pointer[pendingFraming]
+	// streamChunkSize is set once the client agreed to streaming.
+	streamChunkSize atomic.Int64
+	requestIDs      atomic.Bool
+	// lastID is the request ID of the command ReceiveContext returned last,
+	// which Send answers.
+	lastID atomic.Uint32
 }
 
 func NewServerWire(maxMsgSize int, keepAlive int32, clientFD int) (*ServerWire, *wire.WireError) {
@@ -56,27 +69,175 @@ func NewServerWire(maxMsgSize int, keepAlive int32, clientFD int) (*ServerWire,
 		}
 	}
 
-	w := &ServerWire{
+	return NewServerWireFromConn(maxMsgSize, conn), nil
+}
+
+func NewServerWireFromConn(maxMsgSize int, conn net.Conn) *ServerWire {
+	return &ServerWire{
 		ProtobufTCPWire: internal.NewProtobufTCPWire(maxMsgSize, conn),
+		conn:            conn,
 	}
+}
 
-	return w, nil
+// SetIdleTimeout bounds how long Receive and ReceiveContext wait for the next
+// command. Zero, the default, waits indefinitely.
+func (sw *ServerWire) SetIdleTimeout(d time.Duration) {
+	sw.idleTimeout = d
 }
 
+// Send writes resp, giving up when ctx is cancelled or its deadline passes.
+// Once request IDs were negotiated, it answers the command ReceiveContext
+// returned last.
 func (sw *ServerWire) Send(ctx context.Context, resp *wire.Result) *wire.WireError {
-	return sw.ProtobufTCPWire.Send(resp)
+	return sw.SendWithID(ctx, sw.lastID.Load(), resp)
+}
+
+// SendWithID is Send answering the command that carried id, so commands
+// can be answered out of order. The id is ignored unless request IDs were
+// negotiated.
+func (sw *ServerWire) SendWithID(ctx context.Context, id uint32, resp *wire.Result) *wire.WireError {
+	stop, err := bindContext(ctx, sw.conn.SetWriteDeadline, time.Time{})
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	size := proto.Size(resp)
+
+	// Streamed results are only bounded by what the client reads them into,
+	// which it checks itself.
+	tagged := sw.requestIDs.Load()
+	if chunkSize := int(sw.streamChunkSize.Load()); chunkSize > 0 && size > chunkSize {
+		if tagged {
+			return contextError(ctx, sw.ProtobufTCPWire.SendStreamWithID(id, resp, chunkSize))
+		}
+		return contextError(ctx, sw.ProtobufTCPWire.SendStream(resp, chunkSize))
+	}
+
+	if max := sw.Capabilities().MaxFrameSize; max > 0 && size > max {
+		resp = &wire.Result{
+			Status:  wire.Status_ERR,
+			Message: fmt.Sprintf("result of %d bytes exceeds the client's max frame size of %d bytes", size, max),
+		}
+	}
+
+	var sendErr *wire.WireError
+	if tagged {
+		sendErr = sw.ProtobufTCPWire.SendWithID(id, resp)
+	} else {
+		sendErr = sw.ProtobufTCPWire.Send(resp)
+	}
+	if err := contextError(ctx, sendErr); err != nil {
+		return err
+	}
+
+	sw.applyPendingFraming()
+
+	return nil
 }
 
 func (sw *ServerWire) Receive() (*wire.Command, *wire.WireError) {
-	cmd := &wire.Command{}
+	return sw.ReceiveContext(context.Background())
+}
 
-	if err := sw.ProtobufTCPWire.Receive(cmd); err != nil {
+// ReceiveContext reads the next command, giving up when ctx is cancelled, its
+// deadline passes or the idle timeout expires, whichever comes first.
+func (sw *ServerWire) ReceiveContext(ctx context.Context) (*wire.Command, *wire.WireError) {
+	id, cmd, err := sw.ReceiveWithID(ctx)
+	if err != nil {
 		return nil, err
 	}
+	sw.lastID.Store(id)
 
 	return cmd, nil
 }
 
+// ReceiveWithID is ReceiveContext also returning the request ID the command
+// carries, which is zero unless request IDs were negotiated.
+func (sw *ServerWire) ReceiveWithID(ctx context.Context) (uint32, *wire.Command, *wire.WireError) {
+	var idleDeadline time.Time
+	if sw.idleTimeout > 0 {
+		idleDeadline = time.Now().Add(sw.idleTimeout)
+	}
+
+	stop, err := bindContext(ctx, sw.ProtobufTCPWire.SetReadDeadline, idleDeadline)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer stop()
+
+	cmd := &wire.Command{}
+
+	var id uint32
+	if sw.requestIDs.Load() {
+		id, err = sw.ProtobufTCPWire.ReceiveWithID(cmd)
+	} else {
+		err = sw.ProtobufTCPWire.Receive(cmd)
+	}
+	if err := contextError(ctx, err); err != nil {
+		return 0, nil, err
+	}
+
+	return id, cmd, nil
+}
+
 func (sw *ServerWire) Close() {
 	sw.ProtobufTCPWire.Close()
 }
+
+// bindContext sets the earliest of ctx's deadline and fallback on the conn and
+// expires it as soon as ctx is cancelled. The returned stop clears the
+// deadline again.
+func bindContext(ctx context.Context, setDeadline func(time.Time) error, fallback time.Time) (func(), *wire.WireError) {
+	if err := ctx.Err(); err != nil {
+		return nil, contextError(ctx, &wire.WireError{Kind: wire.Terminated, Cause: err})
+	}
+
+	deadline := fallback
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+
+	if err := setDeadline(deadline); err != nil {
+		return nil, &wire.WireError{Kind: wire.Terminated, Cause: err}
+	}
+
+	// The callback can still be running when stop returns, so both hold mu
+	// and the callback does nothing once stopped is set.
+	var mu sync.Mutex
+	stopped := false
+	stopAfter := context.AfterFunc(ctx, func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !stopped {
+			setDeadline(time.Now())
+		}
+	})
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		stopped = true
+		stopAfter()
+		setDeadline(time.Time{})
+	}, nil
+}
+
+// contextError attributes a wire failure to ctx when ctx ended: a cancelled
+// context terminates the wire, an expired one exceeds its deadline.
+func contextError(ctx context.Context, err *wire.WireError) *wire.WireError {
+	if err == nil {
+		return nil
+	}
+
+	switch ctx.Err() {
+	case context.Canceled:
+		return &wire.WireError{Kind: wire.Terminated, Cause: ctx.Err()}
+	case context.DeadlineExceeded:
+		return &wire.WireError{Kind: wire.DeadlineExceeded, Cause: ctx.Err()}
+	default:
+		return err
+	}
+}
@@ -0,0 +1,96 @@
+package dicedb_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func serverWire(t *testing.T) *dicedb.ServerWire {
+	t.Helper()
+
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	return dicedb.NewServerWireFromConn(1024, server)
+}
+
+func TestServerWireReceiveContext(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func() (context.Context, context.CancelFunc)
+		idle time.Duration
+		want wire.ErrKind
+	}{
+		{
+			name: "context deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 20*time.Millisecond)
+			},
+			want: wire.DeadlineExceeded,
+		},
+		{
+			name: "context cancelled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(20*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			want: wire.Terminated,
+		},
+		{
+			name: "idle timeout",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			idle: 20 * time.Millisecond,
+			want: wire.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			sw := serverWire(t)
+			sw.SetIdleTimeout(tt.idle)
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			// act
+			start := time.Now()
+			_, err := sw.ReceiveContext(ctx)
+
+			// assert
+			if err == nil || err.Kind != tt.want {
+				t.Errorf("ReceiveContext() error = %v, want kind %v", err, tt.want)
+			}
+
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("ReceiveContext() returned after %v, want prompt return", elapsed)
+			}
+		})
+	}
+}
+
+func TestServerWireSendDeadline(t *testing.T) {
+	// arrange
+	sw := serverWire(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// act
+	// Nobody reads the other end of the pipe, so the write blocks.
+	err := sw.Send(ctx, &wire.Result{Status: wire.Status_OK, Message: "blocked"})
+
+	// assert
+	if err == nil || err.Kind != wire.DeadlineExceeded {
+		t.Errorf("Send() error = %v, want kind %v", err, wire.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package sevendbmock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+type action int
+
+const (
+	actionRespond action = iota
+	actionCloseConnection
+	actionSendCorruptFrame
+	actionSendOversizedPrefix
+	actionPartialWrite
+)
+
+// Expectation describes how the mock server reacts to a matching command.
+// Expectations are created with Server.Expect and configured by chaining.
+type Expectation struct {
+	cmd      string
+	args     []string
+	anyArgs  bool
+	result   *wire.Result
+	delay    time.Duration
+	action   action
+	partialN int
+	times    int
+	calls    int
+}
+
+func newExpectation(cmd string) *Expectation {
+	return &Expectation{
+		cmd:     cmd,
+		anyArgs: true,
+		// An all-default Result marshals to zero bytes, which the framing
+		// rejects, so the default carries a message.
+		result: &wire.Result{Status: wire.Status_OK, Message: "OK"},
+		action: actionRespond,
+		times:  1,
+	}
+}
+
+// WithArgs restricts the expectation to commands carrying exactly these args.
+func (e *Expectation) WithArgs(args ...string) *Expectation {
+	e.args = args
+	e.anyArgs = false
+	return e
+}
+
+// Return sets the result sent back when the expectation matches. It is kept
+// as given, but one that marshals to zero bytes cannot be framed, so the
+// server sends it with the message "OK" on the wire, like the default result.
+func (e *Expectation) Return(result *wire.Result) *Expectation {
+	e.result = result
+	return e
+}
+
+// Delay makes the server wait before acting on the command.
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// Times sets how many commands the expectation matches. Defaults to 1.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// CloseConnection closes the connection instead of responding, which the
+// client observes as wire.Empty.
+func (e *Expectation) CloseConnection() *Expectation {
+	e.action = actionCloseConnection
+	return e
+}
+
+// SendCorruptFrame responds with a correctly prefixed frame whose payload is
+// not a valid protobuf message, which the client observes as wire.CorruptMessage.
+func (e *Expectation) SendCorruptFrame() *Expectation {
+	e.action = actionSendCorruptFrame
+	return e
+}
+
+// SendOversizedPrefix responds with a length prefix larger than any sane
+// maximum message size, which the client observes as wire.CorruptMessage.
+func (e *Expectation) SendOversizedPrefix() *Expectation {
+	e.action = actionSendOversizedPrefix
+	return e
+}
+
+// PartialWrite writes only the first n bytes of the encoded result frame and
+// then closes the connection, which the client observes as wire.Terminated.
+func (e *Expectation) PartialWrite(n int) *Expectation {
+	e.action = actionPartialWrite
+	e.partialN = n
+	return e
+}
+
+func (e *Expectation) matches(cmd *wire.Command) bool {
+	if e.calls >= e.times || !strings.EqualFold(e.cmd, cmd.Cmd) {
+		return false
+	}
+
+	if e.anyArgs {
+		return true
+	}
+
+	if len(e.args) != len(cmd.Args) {
+		return false
+	}
+
+	for i := range e.args {
+		if e.args[i] != cmd.Args[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *Expectation) String() string {
+	if e.anyArgs {
+		return e.cmd
+	}
+
+	return fmt.Sprintf("%s %s", e.cmd, strings.Join(e.args, " "))
+}
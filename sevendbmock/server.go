@@ -0,0 +1,270 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+// Package sevendbmock provides a scriptable SevenDB server for tests. It
+// speaks the same framing as dicedb.ServerWire and lets tests script
+// responses and inject wire-level faults per command.
+package sevendbmock
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	maxMsgSize         = 32 * 1024 * 1024 // 32 MB
+	oversizedFrameSize = 1 << 28
+)
+
+// corruptPayload is an unterminated varint tag, which protobuf always rejects.
+var corruptPayload = []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+
+type Server struct {
+	listener     net.Listener
+	mu           sync.Mutex
+	expectations []*Expectation
+	unexpected   []*wire.Command
+	conns        map[net.Conn]struct{}
+	closed       bool
+	wg           sync.WaitGroup
+}
+
+// NewServer starts a mock server listening on a random loopback port.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: listener,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *Server) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Expect registers an expectation for cmd. Expectations are matched in the
+// order they were registered. HANDSHAKE commands without a matching
+// expectation are acknowledged automatically; any other unmatched command is
+// answered with an error result and recorded as unexpected.
+func (s *Server) Expect(cmd string) *Expectation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := newExpectation(cmd)
+	s.expectations = append(s.expectations, e)
+
+	return e
+}
+
+// ExpectationsWereMet reports expectations that were not matched as often as
+// required and commands that matched no expectation.
+func (s *Server) ExpectationsWereMet() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var problems []string
+	for _, e := range s.expectations {
+		if e.calls < e.times {
+			problems = append(problems, fmt.Sprintf("expected %q %d time(s), got %d", e, e.times, e.calls))
+		}
+	}
+
+	for _, cmd := range s.unexpected {
+		problems = append(problems, fmt.Sprintf("unexpected command %q", strings.TrimSpace(cmd.Cmd+" "+strings.Join(cmd.Args, " "))))
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// Close stops accepting connections, closes all open ones and waits for
+// their handlers to return.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	sw := dicedb.NewServerWireFromConn(maxMsgSize, conn)
+	defer sw.Close()
+
+	for {
+		cmd, err := sw.Receive()
+		if err != nil {
+			return
+		}
+
+		e := s.match(cmd)
+		if e == nil {
+			if err := sw.Send(context.Background(), s.fallback(cmd)); err != nil {
+				return
+			}
+			continue
+		}
+
+		if e.delay > 0 {
+			time.Sleep(e.delay)
+		}
+
+		if !s.act(e, sw, conn) {
+			return
+		}
+	}
+}
+
+func (s *Server) match(cmd *wire.Command) *Expectation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.expectations {
+		if e.matches(cmd) {
+			e.calls++
+			return e
+		}
+	}
+
+	if !strings.EqualFold(cmd.Cmd, "HANDSHAKE") {
+		s.unexpected = append(s.unexpected, cmd)
+	}
+
+	return nil
+}
+
+func (s *Server) fallback(cmd *wire.Command) *wire.Result {
+	if strings.EqualFold(cmd.Cmd, "HANDSHAKE") {
+		return &wire.Result{
+			Status:   wire.Status_OK,
+			Response: &wire.Result_HANDSHAKERes{HANDSHAKERes: &wire.HANDSHAKERes{}},
+		}
+	}
+
+	return &wire.Result{
+		Status:  wire.Status_ERR,
+		Message: fmt.Sprintf("sevendbmock: unexpected command %s", cmd.Cmd),
+	}
+}
+
+// act performs the expectation's action and reports whether the connection
+// should keep serving commands.
+func (s *Server) act(e *Expectation, sw *dicedb.ServerWire, conn net.Conn) bool {
+	switch e.action {
+	case actionCloseConnection:
+		return false
+	case actionSendCorruptFrame:
+		return writeRaw(conn, frame(corruptPayload))
+	case actionSendOversizedPrefix:
+		buffer := make([]byte, 4)
+		binary.BigEndian.PutUint32(buffer, oversizedFrameSize)
+		return writeRaw(conn, buffer)
+	case actionPartialWrite:
+		payload, err := proto.Marshal(framable(e.result))
+		if err != nil {
+			slog.Error("sevendbmock: failed to marshal result", "error", err)
+			return false
+		}
+
+		buffer := frame(payload)
+		if e.partialN < len(buffer) {
+			buffer = buffer[:e.partialN]
+		}
+		writeRaw(conn, buffer)
+		return false
+	default:
+		return sw.Send(context.Background(), framable(e.result)) == nil
+	}
+}
+
+// framable returns res, or a result with the message "OK" in its place when
+// res marshals to zero bytes, which the framing rejects.
+func framable(res *wire.Result) *wire.Result {
+	if proto.Size(res) > 0 {
+		return res
+	}
+
+	return &wire.Result{Status: res.GetStatus(), Message: "OK"}
+}
+
+func frame(payload []byte) []byte {
+	buffer := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(buffer, uint32(len(payload)))
+	copy(buffer[4:], payload)
+
+	return buffer
+}
+
+func writeRaw(conn net.Conn, buffer []byte) bool {
+	_, err := conn.Write(buffer)
+	return err == nil
+}
@@ -0,0 +1,224 @@
+package sevendbmock
+
+import (
+	"testing"
+	"time"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func TestClientRoundTrip(t *testing.T) {
+	// arrange
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer srv.Close()
+
+	srv.Expect("GET").WithArgs("k").Return(&wire.Result{
+		Status:   wire.Status_OK,
+		Response: &wire.Result_GETRes{GETRes: &wire.GETRes{Value: "v"}},
+	})
+
+	client, err := dicedb.NewClient(srv.Host(), srv.Port())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// act
+	resp := client.Fire(&wire.Command{Cmd: "GET", Args: []string{"k"}})
+
+	// assert
+	if resp.Status != wire.Status_OK || resp.GetGETRes().GetValue() != "v" {
+		t.Errorf("Fire() = %v, want GET value v", resp)
+	}
+
+	if err := srv.ExpectationsWereMet(); err != nil {
+		t.Errorf("ExpectationsWereMet() error = %v", err)
+	}
+}
+
+func TestUnexpectedCommand(t *testing.T) {
+	// arrange
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer srv.Close()
+
+	client, err := dicedb.NewClient(srv.Host(), srv.Port())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// act
+	resp := client.Fire(&wire.Command{Cmd: "PING"})
+
+	// assert
+	if resp.Status != wire.Status_ERR {
+		t.Errorf("Fire() status = %v, want %v", resp.Status, wire.Status_ERR)
+	}
+
+	if err := srv.ExpectationsWereMet(); err == nil {
+		t.Errorf("ExpectationsWereMet() error = nil, want unexpected command")
+	}
+}
+
+func TestFaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		script func(e *Expectation)
+		want   wire.ErrKind
+	}{
+		{
+			name:   "close connection",
+			script: func(e *Expectation) { e.CloseConnection() },
+			want:   wire.Empty,
+		},
+		{
+			name:   "corrupt frame",
+			script: func(e *Expectation) { e.SendCorruptFrame() },
+			want:   wire.CorruptMessage,
+		},
+		{
+			name:   "oversized prefix",
+			script: func(e *Expectation) { e.SendOversizedPrefix() },
+			want:   wire.CorruptMessage,
+		},
+		{
+			name:   "partial prefix",
+			script: func(e *Expectation) { e.PartialWrite(2) },
+			want:   wire.Terminated,
+		},
+		{
+			name: "partial payload",
+			script: func(e *Expectation) {
+				e.Return(&wire.Result{Status: wire.Status_OK, Message: "truncated"}).PartialWrite(6)
+			},
+			want: wire.Terminated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			srv, err := NewServer()
+			if err != nil {
+				t.Fatalf("NewServer() error = %v", err)
+			}
+			defer srv.Close()
+
+			tt.script(srv.Expect("PING"))
+
+			cw, werr := dicedb.NewClientWire(maxMsgSize, srv.Host(), srv.Port())
+			if werr != nil {
+				t.Fatalf("NewClientWire() error = %v", werr)
+			}
+			defer cw.Close()
+
+			// act
+			if werr := cw.Send(&wire.Command{Cmd: "PING"}); werr != nil {
+				t.Fatalf("Send() error = %v", werr)
+			}
+			_, werr = cw.Receive()
+
+			// assert
+			if werr == nil || werr.Kind != tt.want {
+				t.Errorf("Receive() error = %v, want kind %v", werr, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotEstablished(t *testing.T) {
+	// arrange
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	host, port := srv.Host(), srv.Port()
+	srv.Close()
+
+	// act
+	_, werr := dicedb.NewClientWire(maxMsgSize, host, port)
+
+	// assert
+	if werr == nil || werr.Kind != wire.NotEstablished {
+		t.Errorf("NewClientWire() error = %v, want kind %v", werr, wire.NotEstablished)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	// arrange
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer srv.Close()
+
+	delay := 50 * time.Millisecond
+	srv.Expect("PING").Delay(delay).Return(&wire.Result{
+		Status:   wire.Status_OK,
+		Response: &wire.Result_PINGRes{PINGRes: &wire.PINGRes{Message: "PONG"}},
+	})
+
+	client, err := dicedb.NewClient(srv.Host(), srv.Port())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// act
+	start := time.Now()
+	resp := client.Fire(&wire.Command{Cmd: "PING"})
+
+	// assert
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("Fire() returned after %v, want at least %v", elapsed, delay)
+	}
+
+	if resp.GetPINGRes().GetMessage() != "PONG" {
+		t.Errorf("Fire() = %v, want PONG", resp)
+	}
+}
+
+func TestEmptyResultsCanBeSent(t *testing.T) {
+	// arrange
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	defer srv.Close()
+
+	empty := &wire.Result{}
+	srv.Expect("PING")
+	set := srv.Expect("SET").Return(empty)
+
+	client, err := dicedb.NewClient(srv.Host(), srv.Port())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// act
+	pingResp := client.Fire(&wire.Command{Cmd: "PING"})
+	setResp := client.Fire(&wire.Command{Cmd: "SET", Args: []string{"k", "v"}})
+
+	// assert
+	for _, resp := range []*wire.Result{pingResp, setResp} {
+		if resp.Status != wire.Status_OK {
+			t.Errorf("Fire() = %v, want OK", resp)
+		}
+	}
+
+	if set.result != empty {
+		t.Errorf("Return() scripted %v, want the empty result as given", set.result)
+	}
+
+	if err := srv.ExpectationsWereMet(); err != nil {
+		t.Errorf("ExpectationsWereMet() error = %v", err)
+	}
+}
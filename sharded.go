@@ -0,0 +1,233 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+const defaultVirtualNodes = 160
+
+// ShardedClient spreads keys over several clients with a consistent-hash
+// ring. Commands are routed by the keys found through the command table;
+// multi-key commands are split per shard and their results merged. Commands
+// without keys always go to the same node, see keylessClient.
+type ShardedClient struct {
+	mu      sync.RWMutex
+	vnodes  int
+	ring    *hashRing
+	clients map[string]*Client
+}
+
+type shardedOption func(*ShardedClient)
+
+// WithVirtualNodes sets how many points each node occupies on the ring. More
+// points give a more even spread at the cost of a larger ring.
+func WithVirtualNodes(n int) shardedOption {
+	return func(sc *ShardedClient) {
+		sc.vnodes = n
+	}
+}
+
+func NewShardedClient(opts ...shardedOption) *ShardedClient {
+	sc := &ShardedClient{
+		vnodes:  defaultVirtualNodes,
+		clients: make(map[string]*Client),
+	}
+
+	for _, opt := range opts {
+		opt(sc)
+	}
+
+	sc.ring = newHashRing(sc.vnodes)
+
+	return sc
+}
+
+// AddNode adds a shard under name. Only keys that now hash to the new node
+// move to it.
+func (sc *ShardedClient) AddNode(name string, client *Client) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if _, ok := sc.clients[name]; ok {
+		return fmt.Errorf("node %q already exists", name)
+	}
+
+	sc.clients[name] = client
+	sc.ring.add(name)
+
+	return nil
+}
+
+// RemoveNode takes a shard out of the ring and returns its client so the
+// caller can close it once in-flight work has drained.
+func (sc *ShardedClient) RemoveNode(name string) (*Client, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	client, ok := sc.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("node %q does not exist", name)
+	}
+
+	delete(sc.clients, name)
+	sc.ring.remove(name)
+
+	return client, nil
+}
+
+// NodeFor reports the name of the node that owns key.
+func (sc *ShardedClient) NodeFor(key string) (string, bool) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	return sc.ring.locate(key)
+}
+
+func (sc *ShardedClient) Fire(cmd *wire.Command) *wire.Result {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	if len(sc.clients) == 0 {
+		return errResult("no nodes in sharded client")
+	}
+
+	switch strings.ToUpper(cmd.Cmd) {
+	case "KEYS", "FLUSHDB":
+		return sc.fanOut(cmd)
+	}
+
+	keys := commandKeys(cmd)
+	if len(keys) == 0 {
+		return sc.keylessClient(cmd).Fire(cmd)
+	}
+
+	if len(keys) == 1 {
+		node, _ := sc.ring.locate(cmd.Args[keys[0]])
+		return sc.clients[node].Fire(cmd)
+	}
+
+	return sc.split(cmd, keys)
+}
+
+func (sc *ShardedClient) FireString(cmdStr string) *wire.Result {
+	return sc.Fire(parseCommand(cmdStr))
+}
+
+func (sc *ShardedClient) Close() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	for _, client := range sc.clients {
+		client.Close()
+	}
+}
+
+// split fires a command whose args are all keys as one command per shard,
+// keeping the relative order of the keys, and merges the results.
+func (sc *ShardedClient) split(cmd *wire.Command, keys []int) *wire.Result {
+	var nodes []string
+	perNode := make(map[string]*wire.Command)
+	for _, i := range keys {
+		node, _ := sc.ring.locate(cmd.Args[i])
+		sub, ok := perNode[node]
+		if !ok {
+			sub = &wire.Command{Cmd: cmd.Cmd}
+			perNode[node] = sub
+			nodes = append(nodes, node)
+		}
+
+		sub.Args = append(sub.Args, cmd.Args[i])
+	}
+
+	results := make([]*wire.Result, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, client *Client, sub *wire.Command) {
+			defer wg.Done()
+			results[i] = client.Fire(sub)
+		}(i, sc.clients[node], perNode[node])
+	}
+	wg.Wait()
+
+	return mergeResults(cmd, results)
+}
+
+func (sc *ShardedClient) fanOut(cmd *wire.Command) *wire.Result {
+	results := make([]*wire.Result, 0, len(sc.clients))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, client := range sc.clients {
+		wg.Add(1)
+		go func(client *Client) {
+			defer wg.Done()
+			resp := client.Fire(cmd)
+
+			mu.Lock()
+			results = append(results, resp)
+			mu.Unlock()
+		}(client)
+	}
+	wg.Wait()
+
+	return mergeResults(cmd, results)
+}
+
+// keylessClient picks the node for a command without keys in the command
+// table. A command missing from the table is routed by its first argument,
+// which is usually a key, and any other by its name, so the same command
+// always reaches the same node.
+func (sc *ShardedClient) keylessClient(cmd *wire.Command) *Client {
+	route := strings.ToUpper(cmd.Cmd)
+	if _, ok := lookupCommand(cmd.Cmd); !ok && len(cmd.Args) > 0 {
+		route = cmd.Args[0]
+	}
+
+	node, _ := sc.ring.locate(route)
+	return sc.clients[node]
+}
+
+func mergeResults(cmd *wire.Command, results []*wire.Result) *wire.Result {
+	for _, resp := range results {
+		if resp.Status == wire.Status_ERR {
+			return resp
+		}
+	}
+
+	switch strings.ToUpper(cmd.Cmd) {
+	case "DEL":
+		var count int64
+		for _, resp := range results {
+			count += resp.GetDELRes().GetCount()
+		}
+		return &wire.Result{Status: wire.Status_OK, Response: &wire.Result_DELRes{DELRes: &wire.DELRes{Count: count}}}
+	case "EXISTS":
+		var count int64
+		for _, resp := range results {
+			count += resp.GetEXISTSRes().GetCount()
+		}
+		return &wire.Result{Status: wire.Status_OK, Response: &wire.Result_EXISTSRes{EXISTSRes: &wire.EXISTSRes{Count: count}}}
+	case "KEYS":
+		var keys []string
+		for _, resp := range results {
+			keys = append(keys, resp.GetKEYSRes().GetKeys()...)
+		}
+		return &wire.Result{Status: wire.Status_OK, Response: &wire.Result_KEYSRes{KEYSRes: &wire.KEYSRes{Keys: keys}}}
+	default:
+		return results[0]
+	}
+}
+
+func errResult(format string, args ...any) *wire.Result {
+	return &wire.Result{
+		Status:  wire.Status_ERR,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
@@ -0,0 +1,121 @@
+package dicedb_test
+
+import (
+	"fmt"
+	"testing"
+
+	dicedb "github.com/sevenDatabase/SevenDB-go"
+	"github.com/sevenDatabase/SevenDB-go/sevendbmock"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+func TestShardedClientSplitsMultiKeyCommands(t *testing.T) {
+	// arrange
+	sc := dicedb.NewShardedClient()
+	defer sc.Close()
+
+	servers := make(map[string]*sevendbmock.Server)
+	for _, node := range []string{"a", "b"} {
+		srv, err := sevendbmock.NewServer()
+		if err != nil {
+			t.Fatalf("NewServer() error = %v", err)
+		}
+		defer srv.Close()
+
+		client, err := dicedb.NewClient(srv.Host(), srv.Port())
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+
+		if err := sc.AddNode(node, client); err != nil {
+			t.Fatalf("AddNode() error = %v", err)
+		}
+		servers[node] = srv
+	}
+
+	keys := make(map[string]string)
+	for i := 0; len(keys) < 2; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		node, _ := sc.NodeFor(key)
+		if _, ok := keys[node]; !ok {
+			keys[node] = key
+		}
+	}
+
+	for node, srv := range servers {
+		srv.Expect("DEL").WithArgs(keys[node]).Return(&wire.Result{
+			Status:   wire.Status_OK,
+			Response: &wire.Result_DELRes{DELRes: &wire.DELRes{Count: 1}},
+		})
+	}
+
+	// act
+	resp := sc.Fire(&wire.Command{Cmd: "DEL", Args: []string{keys["a"], keys["b"]}})
+
+	// assert
+	if got := resp.GetDELRes().GetCount(); got != 2 {
+		t.Errorf("Fire() DEL count = %d (%v), want 2", got, resp)
+	}
+
+	for node, srv := range servers {
+		if err := srv.ExpectationsWereMet(); err != nil {
+			t.Errorf("node %s: %v", node, err)
+		}
+	}
+}
+
+func TestShardedClientRoutesUnknownCommandsByFirstArgument(t *testing.T) {
+	// arrange
+	sc := dicedb.NewShardedClient()
+	defer sc.Close()
+
+	servers := make(map[string]*sevendbmock.Server)
+	for _, node := range []string{"a", "b", "c"} {
+		srv, err := sevendbmock.NewServer()
+		if err != nil {
+			t.Fatalf("NewServer() error = %v", err)
+		}
+		defer srv.Close()
+
+		client, err := dicedb.NewClient(srv.Host(), srv.Port())
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+
+		if err := sc.AddNode(node, client); err != nil {
+			t.Fatalf("AddNode() error = %v", err)
+		}
+		servers[node] = srv
+	}
+
+	const key, calls = "unknown:key", 10
+	owner, _ := sc.NodeFor(key)
+	servers[owner].Expect("MYCMD").WithArgs(key).Return(&wire.Result{Status: wire.Status_OK}).Times(calls)
+
+	// act
+	var resps []*wire.Result
+	for range calls {
+		resps = append(resps, sc.Fire(&wire.Command{Cmd: "MYCMD", Args: []string{key}}))
+	}
+
+	// assert
+	for i, resp := range resps {
+		if resp.Status != wire.Status_OK {
+			t.Errorf("Fire() call %d = %v, want OK from %s", i, resp, owner)
+		}
+	}
+
+	if err := servers[owner].ExpectationsWereMet(); err != nil {
+		t.Errorf("node %s: %v", owner, err)
+	}
+}
+
+func TestShardedClientWithoutNodes(t *testing.T) {
+	// act
+	resp := dicedb.NewShardedClient().Fire(&wire.Command{Cmd: "GET", Args: []string{"k"}})
+
+	// assert
+	if resp.Status != wire.Status_ERR {
+		t.Errorf("Fire() status = %v, want %v", resp.Status, wire.Status_ERR)
+	}
+}
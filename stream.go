@@ -0,0 +1,324 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package dicedb
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sevenDatabase/SevenDB-go/internal"
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultStreamChunkSize is the size of the chunks large results are
+// streamed in.
+const DefaultStreamChunkSize = internal.DefaultStreamChunkSize
+
+// maxTrailer bounds what Close reads past a fully read value to keep the
+// connection usable. A stream with more left is dropped with its connection.
+const maxTrailer = 64 * 1024
+
+var (
+	getResField   = (&wire.Result{}).ProtoReflect().Descriptor().Fields().ByName("GETRes").Number()
+	getValueField = (&wire.GETRes{}).ProtoReflect().Descriptor().Fields().ByName("value").Number()
+)
+
+// GetStream returns the value of key as a reader that consumes it as it
+// arrives, so values of any size can be read. Commands on the client's main
+// connection wait until the reader is closed, unless the connection is
+// multiplexed, in which case streams use a connection of their own. Closing
+// the reader before the whole value was read drops its connection, which the
+// next command restores. A process hook that answers the GET itself has the
+// value of its result read instead.
+func (c *Client) GetStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	call := &streamCall{}
+	res := c.hooks.Load().stream(context.WithValue(ctx, streamCallKey{}, call), &wire.Command{Cmd: "GET", Args: []string{key}})
+	if res == call.res {
+		return call.body, call.err
+	}
+
+	if call.body != nil {
+		call.body.Close()
+	}
+	if res.Status == wire.Status_ERR {
+		return nil, errors.New(res.Message)
+	}
+
+	return io.NopCloser(strings.NewReader(res.GetGETRes().GetValue())), nil
+}
+
+// streamCall carries a GetStream through the process hooks to processStream.
+type streamCall struct {
+	body io.ReadCloser
+	err  error
+	res  *wire.Result
+}
+
+type streamCallKey struct{}
+
+// openStream sends cmd, a GET, and returns its value as a reader.
+func (c *Client) openStream(ctx context.Context, cmd *wire.Command) (io.ReadCloser, error) {
+	c.mainMu.Lock()
+	if c.muxOf(c.mainWire) == nil {
+		return c.getStream(ctx, cmd, c.mainWire, c.restoreMainWire, c.mainMu.Unlock)
+	}
+	c.mainMu.Unlock()
+
+	c.streamMu.Lock()
+	if err := c.openStreamWire(); err != nil {
+		c.streamMu.Unlock()
+		return nil, err
+	}
+
+	return c.getStream(ctx, cmd, c.streamWire, c.restoreStreamWire, c.streamMu.Unlock)
+}
+
+// getStream sends cmd on clientWire, whose lock the caller holds and which
+// release unlocks once the reader is closed.
+func (c *Client) getStream(ctx context.Context, cmd *wire.Command, clientWire *ClientWire, restore func() *wire.WireError, release func()) (io.ReadCloser, error) {
+	if err := ExecuteVoid(c.mainRetrier, []wire.ErrKind{wire.Terminated}, func() *wire.WireError {
+		return clientWire.Send(cmd)
+	}, restore); err != nil {
+		release()
+		return nil, err
+	}
+
+	stop, err := bindContext(ctx, clientWire.SetReadDeadline, time.Time{})
+	if err != nil {
+		clientWire.Close()
+		release()
+		return nil, err
+	}
+
+	body, err := clientWire.ReceiveStream()
+	if err != nil {
+		stop()
+		clientWire.Close()
+		release()
+		return nil, contextError(ctx, err)
+	}
+
+	s := &valueStream{
+		body:  body,
+		r:     bufio.NewReader(body),
+		limit: c.maxResponseSize,
+		release: func() {
+			stop()
+			release()
+		},
+	}
+	if err := s.open(); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// openStreamWire connects the stream connection unless it already is.
+// Callers hold streamMu.
+func (c *Client) openStreamWire() *wire.WireError {
+	if c.streamWire != nil {
+		return nil
+	}
+
+	clientWire, err := c.newWire()
+	if err != nil {
+		return err
+	}
+
+	if err := c.handshake(clientWire, "command", false); err != nil {
+		c.closeWire(clientWire)
+		return err
+	}
+
+	c.wireMu.Lock()
+	c.streamWire = clientWire
+	c.wireMu.Unlock()
+
+	return nil
+}
+
+func (c *Client) restoreStreamWire() *wire.WireError {
+	return c.restoreWire(c.streamWire, "command")
+}
+
+// valueStream reads the value of a GET result straight off the wire. The
+// fields before the value are small and read up front, the value itself is
+// handed out as it arrives.
+type valueStream struct {
+	body io.ReadCloser
+	r    *bufio.Reader
+	// limit bounds the fields read up front.
+	limit   int
+	value   *io.LimitedReader
+	release func()
+	closed  bool
+}
+
+// open reads the result up to its value and fails for error results.
+func (s *valueStream) open() error {
+	var header []byte
+	for {
+		num, typ, err := readTag(s.r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if num == getResField && typ == protowire.BytesType {
+			if err := s.openValue(); err != nil {
+				return err
+			}
+			return checkHeader(header)
+		}
+
+		if header, err = appendField(header, s.r, num, typ, s.limit); err != nil {
+			return err
+		}
+	}
+
+	// Results without a value, such as errors, end here.
+	s.value = &io.LimitedReader{R: s.r}
+
+	return checkHeader(header)
+}
+
+// openValue reads the GETRes field up to the bytes of its value.
+func (s *valueStream) openValue() error {
+	size, err := binary.ReadUvarint(s.r)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+
+	s.value = &io.LimitedReader{R: s.r}
+	if size == 0 {
+		return nil
+	}
+
+	num, typ, err := readTag(s.r)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	if num != getValueField || typ != protowire.BytesType {
+		return fmt.Errorf("unexpected field %d in GET result", num)
+	}
+
+	length, err := binary.ReadUvarint(s.r)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	s.value.N = int64(length)
+
+	return nil
+}
+
+func (s *valueStream) Read(p []byte) (int, error) {
+	n, err := s.value.Read(p)
+	if errors.Is(err, io.EOF) && s.value.N > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return n, err
+}
+
+func (s *valueStream) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	// Whatever follows a fully read value is small, reading it keeps the
+	// connection usable.
+	if s.value != nil && s.value.N == 0 {
+		io.CopyN(io.Discard, s.r, maxTrailer)
+	}
+
+	s.body.Close()
+	s.release()
+
+	return nil
+}
+
+func checkHeader(header []byte) error {
+	res := &wire.Result{}
+	if err := proto.Unmarshal(header, res); err != nil {
+		return &wire.WireError{Kind: wire.CorruptMessage, Cause: err}
+	}
+
+	if res.Status == wire.Status_ERR {
+		return errors.New(res.Message)
+	}
+
+	return nil
+}
+
+func readTag(r io.ByteReader) (protowire.Number, protowire.Type, error) {
+	tag, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	num, typ := protowire.DecodeTag(tag)
+
+	return num, typ, nil
+}
+
+// appendField copies the field whose tag was just read from r to header,
+// refusing to grow header past limit.
+func appendField(header []byte, r *bufio.Reader, num protowire.Number, typ protowire.Type, limit int) ([]byte, error) {
+	header = protowire.AppendTag(header, num, typ)
+
+	var size uint64
+	switch typ {
+	case protowire.VarintType:
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return protowire.AppendVarint(header, v), nil
+	case protowire.Fixed32Type:
+		size = 4
+	case protowire.Fixed64Type:
+		size = 8
+	case protowire.BytesType:
+		var err error
+		if size, err = binary.ReadUvarint(r); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		header = protowire.AppendVarint(header, size)
+	default:
+		return nil, &wire.WireError{Kind: wire.CorruptMessage, Cause: fmt.Errorf("unsupported wire type %d", typ)}
+	}
+
+	if size > uint64(max(limit-len(header), 0)) {
+		return nil, &wire.WireError{Kind: wire.CorruptMessage, Cause: fmt.Errorf("result field %d too large: %d bytes", num, size)}
+	}
+
+	start := len(header)
+	header = append(header, make([]byte, size)...)
+	if _, err := io.ReadFull(r, header[start:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return header, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,58 @@
+package dicedb
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// countingBody counts the bytes read from it and whether it was closed.
+type countingBody struct {
+	r      io.Reader
+	read   int
+	closed bool
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.read += n
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestValueStreamCloseBoundsTrailer(t *testing.T) {
+	// arrange
+	message, err := proto.Marshal(&wire.Result{Response: &wire.Result_GETRes{GETRes: &wire.GETRes{Value: "value"}}})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	message = protowire.AppendTag(message, 1000, protowire.BytesType)
+	message = protowire.AppendBytes(message, make([]byte, 4*maxTrailer))
+
+	body := &countingBody{r: bytes.NewReader(message)}
+	s := &valueStream{body: body, r: bufio.NewReader(body), limit: len(message), release: func() {}}
+	if err := s.open(); err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	value, err := io.ReadAll(s)
+	if err != nil || string(value) != "value" {
+		t.Fatalf("ReadAll() = %q, %v, want value", value, err)
+	}
+
+	// act
+	s.Close()
+
+	// assert
+	if body.read > 2*maxTrailer || !body.closed {
+		t.Errorf("Close() read %d bytes and closed = %v, want at most %d bytes read and the body closed", body.read, body.closed, 2*maxTrailer)
+	}
+}
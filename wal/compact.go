@@ -0,0 +1,281 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package wal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+// A snapshot file holds the commands that rebuild the keyspace as of its
+// base LSN, as ELEMENT_TYPE_COMMAND records in the segment record format.
+// It is named after the base LSN. Restoring a WAL directory means firing the
+// newest snapshot's commands and then replaying from BaseLSN+1, which
+// NewReader and Follow do on their own.
+const snapshotExt = ".snap"
+
+type Snapshot struct {
+	Path     string
+	BaseLSN  uint64
+	Commands []*wire.Command
+	// timestamp is when the last folded record was written, in milliseconds
+	// since the Unix epoch.
+	timestamp int64
+}
+
+type CompactStats struct {
+	// BaseLSN is the last LSN folded into the snapshot.
+	BaseLSN  uint64
+	Snapshot string
+	// Records is the number of WAL records folded by this compaction, on top
+	// of the previous snapshot.
+	Records         int
+	Commands        int
+	RemovedSegments []string
+}
+
+// Compact folds the records up to toLSN, or the whole log when toLSN is
+// zero, into a snapshot on top of the previous one. It then deletes the
+// older snapshots and every segment that only holds folded records. The
+// newest segment is always kept so a writer reopening the directory
+// continues the LSN sequence. Compaction fails on a damaged record or a
+// mutating command it cannot fold, and leaves the directory untouched when
+// it does.
+func Compact(dir string, toLSN uint64) (*CompactStats, error) {
+	previous, err := LoadSnapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newState()
+	stats := &CompactStats{}
+	var now int64
+	if previous != nil {
+		now = previous.timestamp
+		for _, cmd := range previous.Commands {
+			if err := s.apply(cmd, now); err != nil {
+				return nil, fmt.Errorf("snapshot %s: %w", previous.Path, err)
+			}
+		}
+		stats.BaseLSN = previous.BaseLSN
+		stats.Snapshot = previous.Path
+	}
+
+	if toLSN > 0 && toLSN <= stats.BaseLSN {
+		return stats, nil
+	}
+
+	r, err := NewReader(dir, stats.BaseLSN+1)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	for {
+		e, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if toLSN > 0 && e.Lsn > toLSN {
+			break
+		}
+
+		now = e.Timestamp / 1e6
+		if e.ElementType == ElementType_ELEMENT_TYPE_COMMAND {
+			cmd := &wire.Command{}
+			if err := proto.Unmarshal(e.Payload, cmd); err != nil {
+				return nil, fmt.Errorf("lsn %d: invalid command payload: %w", e.Lsn, err)
+			}
+			if err := s.apply(cmd, now); err != nil {
+				return nil, fmt.Errorf("lsn %d: %w", e.Lsn, err)
+			}
+		}
+
+		stats.BaseLSN = e.Lsn
+		stats.Records++
+	}
+
+	if stats.Records == 0 {
+		return stats, nil
+	}
+
+	cmds := s.commands(now)
+	stats.Commands = len(cmds)
+	stats.Snapshot, err = writeSnapshot(dir, stats.BaseLSN, now, cmds)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.RemovedSegments, err = removeCompacted(dir, stats.BaseLSN)
+
+	return stats, err
+}
+
+// LoadSnapshot reads the newest snapshot in dir. It returns nil when there
+// is none.
+func LoadSnapshot(dir string) (*Snapshot, error) {
+	path, base, err := latestSnapshot(dir)
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	snapshot := &Snapshot{Path: path, BaseLSN: base}
+	r := bufio.NewReader(file)
+	var offset int64
+	for {
+		e, n, err := readRecord(r)
+		if errors.Is(err, io.EOF) {
+			return snapshot, nil
+		}
+		if err != nil {
+			return nil, &Corruption{Segment: path, Offset: offset, Err: err}
+		}
+		offset += int64(n)
+
+		cmd := &wire.Command{}
+		if err := proto.Unmarshal(e.Payload, cmd); err != nil {
+			return nil, &Corruption{Segment: path, Offset: offset, Err: fmt.Errorf("%w: %w", ErrInvalidElement, err)}
+		}
+
+		snapshot.timestamp = e.Timestamp / 1e6
+		snapshot.Commands = append(snapshot.Commands, cmd)
+	}
+}
+
+func latestSnapshot(dir string) (string, uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var path string
+	var base uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, snapshotExt) {
+			continue
+		}
+
+		lsn, err := strconv.ParseUint(strings.TrimSuffix(name, snapshotExt), 10, 64)
+		if err != nil || (path != "" && lsn <= base) {
+			continue
+		}
+
+		path, base = filepath.Join(dir, name), lsn
+	}
+
+	return path, base, nil
+}
+
+// writeSnapshot writes cmds to a temporary file and renames it into place,
+// so a crash never leaves a partial snapshot behind.
+func writeSnapshot(dir string, base uint64, now int64, cmds []*wire.Command) (string, error) {
+	tmp, err := os.CreateTemp(dir, "snapshot-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, cmd := range cmds {
+		payload, err := proto.Marshal(cmd)
+		if err != nil {
+			tmp.Close()
+			return "", err
+		}
+
+		record, err := encodeRecord(&Element{
+			Lsn:         base,
+			Timestamp:   now * 1e6,
+			ElementType: ElementType_ELEMENT_TYPE_COMMAND,
+			Payload:     payload,
+		})
+		if err != nil {
+			tmp.Close()
+			return "", err
+		}
+
+		if _, err := w.Write(record); err != nil {
+			tmp.Close()
+			return "", err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%020d%s", base, snapshotExt))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+
+	return path, syncDir(dir)
+}
+
+// removeCompacted deletes the snapshots older than base and the segments
+// whose records all precede base+1, except for the newest segment.
+func removeCompacted(dir string, base uint64) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, snapshotExt) {
+			continue
+		}
+
+		if lsn, err := strconv.ParseUint(strings.TrimSuffix(name, snapshotExt), 10, 64); err == nil && lsn < base {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for i := 0; i+1 < len(segments) && segments[i+1].firstLSN <= base+1; i++ {
+		slog.Info("removing compacted wal segment", "segment", segments[i].path)
+		if err := os.Remove(segments[i].path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, segments[i].path)
+	}
+
+	return removed, syncDir(dir)
+}
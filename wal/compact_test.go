@@ -0,0 +1,277 @@
+package wal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+func command(line string) *wire.Command {
+	tokens := strings.Fields(line)
+	return &wire.Command{Cmd: tokens[0], Args: tokens[1:]}
+}
+
+func commandLines(cmds []*wire.Command) string {
+	lines := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		lines = append(lines, strings.Join(append([]string{cmd.Cmd}, cmd.Args...), " "))
+	}
+
+	return strings.Join(lines, "; ")
+}
+
+func appendCommands(t *testing.T, w *Writer, lines ...string) {
+	t.Helper()
+
+	for _, line := range lines {
+		payload, err := proto.Marshal(command(line))
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+
+		if _, err := w.Append(&Element{ElementType: ElementType_ELEMENT_TYPE_COMMAND, Payload: payload}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+}
+
+func TestStateFolding(t *testing.T) {
+	tests := []struct {
+		name string
+		cmds []string
+		want string
+	}{
+		{
+			name: "strings",
+			cmds: []string{"SET a 1", "INCR a", "INCRBY a 10", "SET b x", "INCR b", "DEL b", "SET c 1 NX", "SET c 2 NX"},
+			want: "SET a 12; SET c 1",
+		},
+		{
+			name: "hashes",
+			cmds: []string{"HSET h f1 a f2 b", "HSET h f1 c", "SET s v", "HSET s f v"},
+			want: "HSET h f1 c f2 b; SET s v",
+		},
+		{
+			name: "sorted sets",
+			cmds: []string{"ZADD z 3 c 1 a 2 b", "ZADD z XX 5 a 9 d", "ZREM z b", "ZPOPMAX z", "ZADD z INCR 2 c"},
+			want: "ZADD z 5 c",
+		},
+		{
+			name: "geo",
+			cmds: []string{"GEOADD g 15.087269 37.502669 Catania 13.361389 38.115556 Palermo", "GEOADD g XX 0 0 nowhere", "GEOADD bad 200 0 x"},
+			want: "ZADD g 3479099956230698 Palermo 3479447370796909 Catania",
+		},
+		{
+			name: "expiry",
+			cmds: []string{"SET a 1 PX 500", "SET b 1 EX 10", "HSET h f v", "EXPIRE h 5", "SET gone 1", "EXPIRE gone 0"},
+			want: "SET a 1 PXAT 1500; SET b 1 PXAT 11000; HSET h f v; EXPIREAT h 6",
+		},
+		{
+			name: "flush",
+			cmds: []string{"SET a 1", "FLUSHDB", "SET b 2", "GET b"},
+			want: "SET b 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			s := newState()
+
+			// act
+			for _, line := range tt.cmds {
+				if err := s.apply(command(line), 1000); err != nil {
+					t.Fatalf("apply(%q) error = %v", line, err)
+				}
+			}
+
+			// assert
+			if got := commandLines(s.commands(1000)); got != tt.want {
+				t.Errorf("commands() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompact(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	opts := Options{SegmentSize: 128, SyncPolicy: SyncNever}
+	w, err := OpenWriter(dir, opts)
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	appendCommands(t, w, "SET a 1", "SET b 1", "INCR a", "DEL b", "HSET h f v", "ZADD z 1 m", "SET c 1", "INCR a")
+	w.Close()
+
+	// act
+	first, err := Compact(dir, 6)
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	second, err := Compact(dir, 0)
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	snapshot, err := LoadSnapshot(dir)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+
+	w, err = OpenWriter(dir, opts)
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	lsn, err := w.Append(&Element{})
+	w.Close()
+
+	// assert
+	if first.BaseLSN != 6 || first.Records != 6 || len(first.RemovedSegments) == 0 {
+		t.Errorf("first Compact() = %+v, want base 6 with segments removed", first)
+	}
+	if second.BaseLSN != 8 || second.Records != 2 {
+		t.Errorf("second Compact() = %+v, want base 8 after 2 more records", second)
+	}
+
+	if snapshot.BaseLSN != 8 || commandLines(snapshot.Commands) != "SET a 3; SET c 1; HSET h f v; ZADD z 1 m" {
+		t.Errorf("LoadSnapshot() = %d %q", snapshot.BaseLSN, commandLines(snapshot.Commands))
+	}
+
+	if _, err := os.Stat(first.Snapshot); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("older snapshot %s still exists", first.Snapshot)
+	}
+
+	if err != nil || lsn != 9 {
+		t.Errorf("Append() after compaction = %d, %v, want lsn 9", lsn, err)
+	}
+}
+
+func TestCompactRejectsUnsupportedCommand(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, Options{SyncPolicy: SyncNever})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	appendCommands(t, w, "SET a 1", "LPUSH l a")
+	w.Close()
+
+	// act
+	_, err = Compact(dir, 0)
+	snapshot, loadErr := LoadSnapshot(dir)
+
+	// assert
+	if !errors.Is(err, ErrUnsupportedCommand) {
+		t.Errorf("Compact() error = %v, want ErrUnsupportedCommand", err)
+	}
+	if snapshot != nil || loadErr != nil {
+		t.Errorf("LoadSnapshot() = %v, %v, want no snapshot", snapshot, loadErr)
+	}
+}
+
+func TestReplayAfterCompact(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, Options{SyncPolicy: SyncNever})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	appendCommands(t, w, "SET a 1", "SET b 1", "INCR a", "SET c 1", "SET d 1")
+	w.Close()
+
+	if _, err := Compact(dir, 3); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	opts := ReplayOptions{CheckpointFile: checkpoint}
+
+	// act
+	_, failErr := replay(t, dir, &recordingFirer{failOn: "b"}, opts)
+	lsn, readErr := ReadCheckpoint(checkpoint)
+	firer := &recordingFirer{}
+	stats, err := replay(t, dir, firer, opts)
+
+	// assert
+	if failErr == nil {
+		t.Fatal("Replay() error = nil, want rejected command")
+	}
+	if readErr != nil || lsn != 0 {
+		t.Errorf("ReadCheckpoint() = %d, %v, want no checkpoint inside the snapshot", lsn, readErr)
+	}
+
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if fmt.Sprint(firer.fired) != "[a b c d]" || stats.LastLSN != 5 {
+		t.Errorf("Replay() fired %v up to lsn %d, want the snapshot's [a b] then [c d] up to lsn 5", firer.fired, stats.LastLSN)
+	}
+}
+
+func TestFollowAfterCompact(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, Options{SyncPolicy: SyncNever})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	appendCommands(t, w, "SET a 1", "SET b 1", "INCR a", "SET c 1")
+	w.Close()
+
+	if _, err := Compact(dir, 3); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// act
+	lsns := collect(t, Follow(ctx, dir, 0, WithPollInterval(10*time.Millisecond)), 3)
+
+	// assert
+	if fmt.Sprint(lsns) != "[3 3 4]" {
+		t.Errorf("Follow() sent lsns %v, want the snapshot's two commands at 3, then 4", lsns)
+	}
+}
+
+func TestSnapshotBatchesLargeKeys(t *testing.T) {
+	// arrange
+	s := newState()
+	value := strings.Repeat("v", 1000)
+	for i := 0; i < 200; i++ {
+		if err := s.apply(&wire.Command{Cmd: "HSET", Args: []string{"h", fmt.Sprintf("f%03d", i), value}}, 0); err != nil {
+			t.Fatalf("apply() error = %v", err)
+		}
+	}
+
+	// act
+	cmds := s.commands(0)
+
+	// assert
+	if len(cmds) < 2 {
+		t.Fatalf("commands() = %d commands, want the hash split", len(cmds))
+	}
+
+	fields := 0
+	for _, cmd := range cmds {
+		size := 0
+		for _, arg := range cmd.Args[1:] {
+			size += len(arg)
+		}
+		if cmd.Cmd != "HSET" || cmd.Args[0] != "h" || size > snapshotBatchSize {
+			t.Errorf("commands() returned %s %s with %d bytes of fields, want HSET h within %d", cmd.Cmd, cmd.Args[0], size, snapshotBatchSize)
+		}
+		fields += (len(cmd.Args) - 1) / 2
+	}
+
+	if fields != 200 {
+		t.Errorf("commands() set %d fields, want 200", fields)
+	}
+}
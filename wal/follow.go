@@ -0,0 +1,257 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package wal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const defaultPollInterval = 100 * time.Millisecond
+
+type follower struct {
+	dir          string
+	next         uint64
+	pollInterval time.Duration
+	segment      segment
+	file         *os.File
+	offset       int64
+	// reported is the offset of the last damaged record logged, so a record
+	// the follower keeps waiting on is only reported once.
+	reported int64
+}
+
+type followOption func(*follower)
+
+// WithPollInterval sets how often Follow checks the WAL for new records.
+func WithPollInterval(d time.Duration) followOption {
+	return func(f *follower) {
+		f.pollInterval = d
+	}
+}
+
+// Follow streams the records of the WAL in dir whose LSN is at least
+// fromLSN, first the ones already written and then new ones as they are
+// appended. It polls the directory, so it follows segment rotation and
+// picks up where it was after a writer restarts or truncates a torn tail.
+// Records compaction folded away are sent as their snapshot's commands, as
+// NewReader returns them. A damaged record stops the stream until it is
+// repaired. The channel is closed once ctx is done.
+func Follow(ctx context.Context, dir string, fromLSN uint64, opts ...followOption) <-chan *Element {
+	f := &follower{
+		dir:          dir,
+		next:         fromLSN,
+		pollInterval: defaultPollInterval,
+		reported:     -1,
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	ch := make(chan *Element)
+	go f.run(ctx, ch)
+
+	return ch
+}
+
+func (f *follower) run(ctx context.Context, ch chan<- *Element) {
+	defer close(ch)
+	defer f.closeFile()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		caughtUp, err := f.poll(ctx, ch)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("failed to follow wal", "dir", f.dir, "error", err)
+		}
+
+		if caughtUp {
+			timer.Reset(f.pollInterval)
+		} else {
+			timer.Reset(0)
+		}
+	}
+}
+
+// poll sends every record that can be read right now and reports whether
+// the follower has caught up with the writer.
+func (f *follower) poll(ctx context.Context, ch chan<- *Element) (bool, error) {
+	if f.file == nil {
+		if err := f.sendSnapshot(ctx, ch); err != nil {
+			return true, err
+		}
+		if err := f.open(); err != nil || f.file == nil {
+			return true, err
+		}
+	}
+
+	if err := f.drain(ctx, ch); err != nil || f.file == nil {
+		return f.file != nil, err
+	}
+
+	segments, err := listSegments(f.dir)
+	if err != nil {
+		return true, err
+	}
+
+	var successor *segment
+	for i := range segments {
+		if segments[i].firstLSN > f.segment.firstLSN {
+			successor = &segments[i]
+			break
+		}
+	}
+	if successor == nil {
+		return true, nil
+	}
+
+	// The writer is done with a segment before it creates the next one, so
+	// one more pass reads whatever was appended since the last one.
+	if err := f.drain(ctx, ch); err != nil || f.file == nil {
+		return f.file != nil, err
+	}
+
+	f.closeFile()
+	return false, f.openSegment(*successor)
+}
+
+// sendSnapshot sends the commands of the newest snapshot when it folded
+// f.next, as NewReader returns them, and moves on past its base LSN.
+func (f *follower) sendSnapshot(ctx context.Context, ch chan<- *Element) error {
+	snapshot, err := LoadSnapshot(f.dir)
+	if err != nil || snapshot == nil || snapshot.BaseLSN < f.next {
+		return err
+	}
+
+	for _, cmd := range snapshot.Commands {
+		payload, err := proto.Marshal(cmd)
+		if err != nil {
+			return err
+		}
+
+		e := &Element{
+			Lsn:         snapshot.BaseLSN,
+			Timestamp:   snapshot.timestamp * 1e6,
+			ElementType: ElementType_ELEMENT_TYPE_COMMAND,
+			Payload:     payload,
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ch <- e:
+		}
+	}
+	f.next = snapshot.BaseLSN + 1
+
+	return nil
+}
+
+// open opens the segment holding f.next, if any.
+func (f *follower) open() error {
+	segments, err := listSegments(f.dir)
+	if err != nil || len(segments) == 0 {
+		return err
+	}
+
+	s := segments[0]
+	for _, candidate := range segments[1:] {
+		if candidate.firstLSN > f.next {
+			break
+		}
+		s = candidate
+	}
+
+	return f.openSegment(s)
+}
+
+func (f *follower) openSegment(s segment) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+
+	f.segment = s
+	f.file = file
+	f.offset = 0
+
+	return nil
+}
+
+// drain reads and sends the complete records after f.offset. It closes the
+// file when the segment was removed, and starts over when it was truncated
+// below f.offset, both of which a restarting writer may do.
+func (f *follower) drain(ctx context.Context, ch chan<- *Element) error {
+	info, err := os.Stat(f.segment.path)
+	if errors.Is(err, os.ErrNotExist) {
+		f.closeFile()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.Size() < f.offset {
+		f.offset = 0
+	}
+
+	if _, err := f.file.Seek(f.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(f.file)
+	for {
+		e, n, err := readRecord(r)
+		if errors.Is(err, io.EOF) || errors.Is(err, ErrTruncated) {
+			// A truncated record is most likely still being written.
+			return nil
+		}
+		if err != nil {
+			if !isRecordError(err) {
+				return err
+			}
+			if f.reported != f.offset {
+				f.reported = f.offset
+				slog.Warn("wal follower waiting on damaged record", "error", &Corruption{Segment: f.segment.path, Offset: f.offset, Err: err})
+			}
+			return nil
+		}
+		f.offset += int64(n)
+
+		if e.Lsn < f.next {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ch <- e:
+			f.next = e.Lsn + 1
+		}
+	}
+}
+
+func (f *follower) closeFile() {
+	if f.file == nil {
+		return
+	}
+
+	f.file.Close()
+	f.file = nil
+}
@@ -0,0 +1,122 @@
+package wal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func collect(t *testing.T, ch <-chan *Element, n int) []uint64 {
+	t.Helper()
+
+	var lsns []uint64
+	timeout := time.After(5 * time.Second)
+	for len(lsns) < n {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				t.Fatalf("follow channel closed after %v", lsns)
+			}
+			lsns = append(lsns, e.Lsn)
+		case <-timeout:
+			t.Fatalf("timed out after %v, want %d elements", lsns, n)
+		}
+	}
+
+	return lsns
+}
+
+func lsnRange(from, to uint64) string {
+	var lsns []uint64
+	for lsn := from; lsn <= to; lsn++ {
+		lsns = append(lsns, lsn)
+	}
+
+	return fmt.Sprint(lsns)
+}
+
+func TestFollowAcrossRotationAndRestart(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	opts := Options{SegmentSize: 128, SyncPolicy: SyncNever}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := Follow(ctx, dir, 0, WithPollInterval(5*time.Millisecond))
+
+	// act
+	w, err := OpenWriter(dir, opts)
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	appendN(t, w, 10)
+	first := collect(t, ch, 10)
+	w.Close()
+
+	w, err = OpenWriter(dir, opts)
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	defer w.Close()
+	appendN(t, w, 10)
+	second := collect(t, ch, 10)
+
+	// assert
+	if got := fmt.Sprint(append(first, second...)); got != lsnRange(1, 20) {
+		t.Errorf("followed %s, want %s", got, lsnRange(1, 20))
+	}
+}
+
+func TestFollowFromLSN(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, Options{SegmentSize: 128, SyncPolicy: SyncNever})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	defer w.Close()
+	appendN(t, w, 15)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// act
+	ch := Follow(ctx, dir, 12, WithPollInterval(5*time.Millisecond))
+	got := collect(t, ch, 4)
+	appendN(t, w, 1)
+	cancel()
+
+	// assert
+	if fmt.Sprint(got) != lsnRange(12, 15) {
+		t.Errorf("followed %v, want %s", got, lsnRange(12, 15))
+	}
+
+	for range ch {
+	}
+}
+
+func TestFollowWaitsForTornTail(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	path, _ := writeSegment(t, dir, 3)
+	tearTail(t, path, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := Follow(ctx, dir, 0, WithPollInterval(5*time.Millisecond))
+	before := collect(t, ch, 2)
+
+	// act
+	w, err := OpenWriter(dir, Options{Recovery: RecoverTruncate, SyncPolicy: SyncNever})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	defer w.Close()
+	appendN(t, w, 2)
+	after := collect(t, ch, 2)
+
+	// assert
+	if got := fmt.Sprint(append(before, after...)); got != lsnRange(1, 4) {
+		t.Errorf("followed %s, want %s", got, lsnRange(1, 4))
+	}
+}
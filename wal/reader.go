@@ -0,0 +1,178 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package wal
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+)
+
+// Reader iterates the records of a WAL directory in LSN order, across
+// segment boundaries.
+type Reader struct {
+	segments []segment
+	// snapshot is set when segments[0] is a snapshot standing in for the
+	// compacted records, which is read whole.
+	snapshot    bool
+	fromLSN     uint64
+	policy      RecoveryPolicy
+	corruptions []*Corruption
+	current     int
+	file        *os.File
+	reader      *bufio.Reader
+	offset      int64
+}
+
+type readerOption func(*Reader)
+
+// WithRecovery selects how damaged records are handled. The default is
+// RecoverFail. RecoverTruncate is refused, a log is only repaired by
+// OpenWriter.
+func WithRecovery(policy RecoveryPolicy) readerOption {
+	return func(r *Reader) {
+		r.policy = policy
+	}
+}
+
+// NewReader returns a reader positioned at the first record whose LSN is at
+// least fromLSN. When compaction folded records from fromLSN on into a
+// snapshot, the reader first returns the snapshot's commands, all with its
+// base LSN, and then the records after it. The segments it will read are
+// checked up front; see RecoveryPolicy for what happens to damaged records.
+func NewReader(dir string, fromLSN uint64, opts ...readerOption) (*Reader, error) {
+	r := &Reader{
+		fromLSN: fromLSN,
+		current: -1,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.policy == RecoverTruncate {
+		return nil, errors.New("RecoverTruncate is only supported by OpenWriter")
+	}
+
+	snapshot, base, err := latestSnapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != "" && fromLSN <= base {
+		r.snapshot = true
+		r.fromLSN = base + 1
+	}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip segments that end before fromLSN: a segment's records all precede
+	// the first LSN of the segment after it.
+	start := 0
+	for i := 1; i < len(segments) && segments[i].firstLSN <= r.fromLSN; i++ {
+		start = i
+	}
+	segments = segments[start:]
+	if r.snapshot {
+		segments = append([]segment{{path: snapshot, firstLSN: base}}, segments...)
+	}
+
+	r.segments, r.corruptions, err = recoverSegments(segments, 0, r.policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Corruptions lists the damaged records found when the reader was opened.
+func (r *Reader) Corruptions() []*Corruption {
+	return r.corruptions
+}
+
+// Next returns the next record, or io.EOF once every segment is exhausted.
+func (r *Reader) Next() (*Element, error) {
+	for {
+		if r.reader == nil {
+			if err := r.openNext(); err != nil {
+				return nil, err
+			}
+		}
+
+		e, n, err := readRecord(r.reader)
+		if errors.Is(err, io.EOF) {
+			r.closeFile()
+			continue
+		}
+		if err != nil {
+			if r.policy == RecoverSkip && isRecordError(err) {
+				r.skip(n, err)
+				continue
+			}
+
+			return nil, &Corruption{Segment: r.segments[r.current].path, Offset: r.offset, Err: err}
+		}
+		r.offset += int64(n)
+
+		if e.Lsn < r.fromLSN && !r.inSnapshot() {
+			continue
+		}
+
+		return e, nil
+	}
+}
+
+// inSnapshot reports whether the reader is still returning the commands of
+// a snapshot.
+func (r *Reader) inSnapshot() bool {
+	return r.snapshot && r.current == 0
+}
+
+// skip moves past a damaged record, or past the rest of its segment when the
+// record's length cannot be trusted.
+func (r *Reader) skip(n int, err error) {
+	if !resumable(err) {
+		r.closeFile()
+		return
+	}
+
+	r.offset += int64(n)
+}
+
+func (r *Reader) Close() error {
+	return r.closeFile()
+}
+
+func (r *Reader) openNext() error {
+	if r.current+1 >= len(r.segments) {
+		return io.EOF
+	}
+	r.current++
+
+	file, err := os.Open(r.segments[r.current].path)
+	if err != nil {
+		return err
+	}
+
+	r.file = file
+	r.reader = bufio.NewReader(file)
+	r.offset = 0
+
+	return nil
+}
+
+func (r *Reader) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+	r.reader = nil
+
+	return err
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package wal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// RecoveryPolicy decides what happens to damaged records found when a WAL is
+// opened.
+type RecoveryPolicy int
+
+const (
+	// RecoverFail refuses to open a WAL that holds any damaged record.
+	RecoverFail RecoveryPolicy = iota
+	// RecoverTruncate cuts the log back to the last good record: the damaged
+	// segment is truncated and every later segment is deleted. Only
+	// OpenWriter accepts it, so files are never rewritten under a writer.
+	RecoverTruncate
+	// RecoverSkip leaves the files untouched and skips damaged records. When
+	// a record's length cannot be trusted the rest of its segment is skipped.
+	// OpenWriter only cuts off a torn final record before appending.
+	RecoverSkip
+)
+
+// Corruption locates a damaged record. Err wraps one of ErrTruncated,
+// ErrInvalidSize, ErrChecksum or ErrInvalidElement.
+type Corruption struct {
+	Segment string
+	Offset  int64
+	Err     error
+}
+
+func (c *Corruption) Error() string {
+	return fmt.Sprintf("wal: corrupt record in %s at offset %d: %v", c.Segment, c.Offset, c.Err)
+}
+
+func (c *Corruption) Unwrap() error {
+	return c.Err
+}
+
+type segmentCheck struct {
+	lastLSN uint64
+	records int
+	// goodSize is the offset right after the last good record that precedes
+	// the first problem, or the segment size when there is none.
+	goodSize int64
+	problems []*Corruption
+}
+
+// checkSegment reads every record of a segment. Unless skip is set it stops
+// at the first problem.
+func checkSegment(path string, skip bool) (*segmentCheck, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	check := &segmentCheck{}
+	r := bufio.NewReader(file)
+	var offset int64
+	for {
+		e, n, err := readRecord(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if !isRecordError(err) {
+				return nil, err
+			}
+
+			check.problems = append(check.problems, &Corruption{Segment: path, Offset: offset, Err: err})
+			if !skip || !resumable(err) {
+				break
+			}
+
+			offset += int64(n)
+			continue
+		}
+
+		offset += int64(n)
+		check.lastLSN = e.Lsn
+		check.records++
+		if len(check.problems) == 0 {
+			check.goodSize = offset
+		}
+	}
+
+	if len(check.problems) == 0 {
+		check.goodSize = offset
+	}
+
+	return check, nil
+}
+
+// recoverSegments checks segments[from:] according to policy and returns the
+// problems found. With RecoverTruncate the files are repaired and the
+// returned segment list reflects what is left on disk.
+func recoverSegments(segments []segment, from int, policy RecoveryPolicy) ([]segment, []*Corruption, error) {
+	var found []*Corruption
+	for i := from; i < len(segments); i++ {
+		check, err := checkSegment(segments[i].path, policy == RecoverSkip)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(check.problems) == 0 {
+			continue
+		}
+		found = append(found, check.problems...)
+
+		switch policy {
+		case RecoverFail:
+			return nil, found, check.problems[0]
+		case RecoverTruncate:
+			if err := truncateFrom(segments, i, check.goodSize); err != nil {
+				return nil, found, err
+			}
+			return segments[:i+1], found, nil
+		}
+	}
+
+	return segments, found, nil
+}
+
+// truncateFrom cuts segments[i] down to size and deletes every later segment.
+func truncateFrom(segments []segment, i int, size int64) error {
+	slog.Warn("truncating wal after damaged record", "segment", segments[i].path, "offset", size)
+
+	if err := os.Truncate(segments[i].path, size); err != nil {
+		return err
+	}
+
+	for _, s := range segments[i+1:] {
+		slog.Warn("removing wal segment after damaged record", "segment", s.path)
+		if err := os.Remove(s.path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isRecordError(err error) bool {
+	return errors.Is(err, ErrTruncated) || errors.Is(err, ErrInvalidSize) || resumable(err)
+}
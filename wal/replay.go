@@ -0,0 +1,207 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package wal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Firer executes a single command. *dicedb.Client satisfies it.
+type Firer interface {
+	Fire(cmd *wire.Command) *wire.Result
+}
+
+type ReplayOptions struct {
+	// FromLSN and ToLSN bound the replayed range, both inclusive. A zero
+	// ToLSN replays to the end of the log.
+	FromLSN uint64
+	ToLSN   uint64
+
+	// DryRun decodes every command without firing it.
+	DryRun bool
+
+	// Rate caps the number of commands fired per second; zero means no limit.
+	Rate float64
+
+	// CheckpointFile records the LSN of the last applied command. When it
+	// already exists, replay resumes after the LSN it holds.
+	CheckpointFile string
+	// CheckpointEvery is the number of commands applied between checkpoint
+	// writes. It defaults to 1; the checkpoint is always written when replay
+	// stops.
+	CheckpointEvery int
+}
+
+type ReplayStats struct {
+	Applied int
+	// Skipped counts NOOP elements and elements at or below the checkpoint.
+	Skipped int
+	LastLSN uint64
+}
+
+// Replay fires the commands read from r through client in LSN order,
+// starting with those of a snapshot when r returns one. NOOP elements are
+// skipped. Replay stops at the first command the server
+// rejects; with a checkpoint file, running it again continues from there.
+func Replay(ctx context.Context, r *Reader, client Firer, opts ReplayOptions) (*ReplayStats, error) {
+	if opts.CheckpointEvery <= 0 {
+		opts.CheckpointEvery = 1
+	}
+
+	stats := &ReplayStats{}
+	from := opts.FromLSN
+	if opts.CheckpointFile != "" {
+		checkpoint, err := ReadCheckpoint(opts.CheckpointFile)
+		if err != nil {
+			return stats, err
+		}
+		stats.LastLSN = checkpoint
+		if checkpoint >= from {
+			from = checkpoint + 1
+		}
+	}
+
+	var interval time.Duration
+	if opts.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / opts.Rate)
+	}
+
+	var next time.Time
+	pending := 0
+	err := func() error {
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			e, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if opts.ToLSN > 0 && e.Lsn > opts.ToLSN {
+				return nil
+			}
+			if e.Lsn < from || e.ElementType == ElementType_ELEMENT_TYPE_NOOP {
+				stats.Skipped++
+				continue
+			}
+
+			cmd := &wire.Command{}
+			if err := proto.Unmarshal(e.Payload, cmd); err != nil {
+				return fmt.Errorf("lsn %d: invalid command payload: %w", e.Lsn, err)
+			}
+
+			if opts.DryRun {
+				stats.Applied++
+				stats.LastLSN = e.Lsn
+				continue
+			}
+
+			if interval > 0 {
+				if err := waitUntil(ctx, next); err != nil {
+					return err
+				}
+				next = time.Now().Add(interval)
+			}
+
+			if res := client.Fire(cmd); res.Status == wire.Status_ERR {
+				return fmt.Errorf("lsn %d: %s failed: %s", e.Lsn, cmd.Cmd, res.Message)
+			}
+
+			stats.Applied++
+			stats.LastLSN = e.Lsn
+
+			// A snapshot's commands share its base LSN, so the checkpoint
+			// only moves once they were all applied.
+			if pending++; opts.CheckpointFile != "" && pending >= opts.CheckpointEvery && !r.inSnapshot() {
+				if err := WriteCheckpoint(opts.CheckpointFile, stats.LastLSN); err != nil {
+					return err
+				}
+				pending = 0
+			}
+		}
+	}()
+
+	if pending > 0 && opts.CheckpointFile != "" && !r.inSnapshot() {
+		if cerr := WriteCheckpoint(opts.CheckpointFile, stats.LastLSN); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return stats, err
+}
+
+// ReadCheckpoint returns the LSN stored in a checkpoint file, or zero when
+// the file does not exist.
+func ReadCheckpoint(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	lsn, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+
+	return lsn, nil
+}
+
+// WriteCheckpoint atomically replaces the checkpoint file with lsn.
+func WriteCheckpoint(path string, lsn uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strconv.FormatUint(lsn, 10) + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func waitUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
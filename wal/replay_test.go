@@ -0,0 +1,139 @@
+package wal
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+type recordingFirer struct {
+	fired  []string
+	failOn string
+}
+
+func (f *recordingFirer) Fire(cmd *wire.Command) *wire.Result {
+	if len(cmd.Args) > 0 && cmd.Args[0] == f.failOn {
+		return &wire.Result{Status: wire.Status_ERR, Message: "rejected"}
+	}
+
+	f.fired = append(f.fired, cmd.Args[0])
+	return &wire.Result{Status: wire.Status_OK, Message: "OK"}
+}
+
+// writeCommands writes a NOOP followed by a SET for every key.
+func writeCommands(t *testing.T, dir string, keys ...string) {
+	t.Helper()
+
+	w, err := OpenWriter(dir, Options{SyncPolicy: SyncNever})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	defer w.Close()
+
+	for _, key := range keys {
+		payload, err := proto.Marshal(&wire.Command{Cmd: "SET", Args: []string{key, "v"}})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+
+		if _, err := w.Append(&Element{ElementType: ElementType_ELEMENT_TYPE_NOOP}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		if _, err := w.Append(&Element{ElementType: ElementType_ELEMENT_TYPE_COMMAND, Payload: payload}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+}
+
+func replay(t *testing.T, dir string, firer Firer, opts ReplayOptions) (*ReplayStats, error) {
+	t.Helper()
+
+	r, err := NewReader(dir, 0)
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	defer r.Close()
+
+	return Replay(context.Background(), r, firer, opts)
+}
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      ReplayOptions
+		wantFired []string
+		wantStats ReplayStats
+	}{
+		{
+			name:      "everything",
+			wantFired: []string{"a", "b", "c"},
+			wantStats: ReplayStats{Applied: 3, Skipped: 3, LastLSN: 6},
+		},
+		{
+			name:      "lsn range",
+			opts:      ReplayOptions{FromLSN: 3, ToLSN: 4},
+			wantFired: []string{"b"},
+			wantStats: ReplayStats{Applied: 1, Skipped: 3, LastLSN: 4},
+		},
+		{
+			name:      "dry run",
+			opts:      ReplayOptions{DryRun: true},
+			wantStats: ReplayStats{Applied: 3, Skipped: 3, LastLSN: 6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			dir := t.TempDir()
+			writeCommands(t, dir, "a", "b", "c")
+			firer := &recordingFirer{}
+
+			// act
+			stats, err := replay(t, dir, firer, tt.opts)
+
+			// assert
+			if err != nil {
+				t.Fatalf("Replay() error = %v", err)
+			}
+			if fmt.Sprint(firer.fired) != fmt.Sprint(tt.wantFired) {
+				t.Errorf("fired %v, want %v", firer.fired, tt.wantFired)
+			}
+			if *stats != tt.wantStats {
+				t.Errorf("Replay() stats = %+v, want %+v", *stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestReplayResumesFromCheckpoint(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	writeCommands(t, dir, "a", "b", "c")
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	opts := ReplayOptions{CheckpointFile: checkpoint}
+
+	// act
+	_, failErr := replay(t, dir, &recordingFirer{failOn: "b"}, opts)
+	lsn, readErr := ReadCheckpoint(checkpoint)
+	firer := &recordingFirer{}
+	_, err := replay(t, dir, firer, opts)
+
+	// assert
+	if failErr == nil {
+		t.Fatal("Replay() error = nil, want rejected command")
+	}
+	if readErr != nil || lsn != 2 {
+		t.Errorf("ReadCheckpoint() = %d, %v, want 2", lsn, readErr)
+	}
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if fmt.Sprint(firer.fired) != "[b c]" {
+		t.Errorf("resumed replay fired %v, want [b c]", firer.fired)
+	}
+}
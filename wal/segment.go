@@ -0,0 +1,198 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sevenDatabase/SevenDB-go/internal"
+	"google.golang.org/protobuf/proto"
+)
+
+// A segment file holds a sequence of records, each laid out as
+//
+//	[4-byte big-endian payload length][4-byte big-endian CRC32C of payload][payload]
+//
+// where the payload is a marshaled Element. The length prefix is the same
+// one internal.TCPWire puts in front of every frame. Segments are named after
+// the LSN of their first record, zero-padded so lexical order is LSN order.
+const (
+	segmentExt       = ".wal"
+	recordHeaderSize = internal.PrefixSize + 4 // length + checksum
+	maxRecordSize    = 64 * 1024 * 1024
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	// ErrTruncated reports a segment that ends in the middle of a record,
+	// typically a write torn by a crash.
+	ErrTruncated = errors.New("truncated record")
+	// ErrInvalidSize reports a length prefix that cannot belong to a record.
+	// Nothing after it in the segment can be located.
+	ErrInvalidSize = errors.New("invalid record size")
+	// ErrChecksum reports a record whose payload does not match its CRC32C.
+	ErrChecksum = errors.New("checksum mismatch")
+	// ErrInvalidElement reports a record that passed its checksum but does
+	// not hold an Element.
+	ErrInvalidElement = errors.New("invalid element")
+	// ErrRecordTooLarge reports an Element whose record would exceed the
+	// size readers accept. Nothing is written for it.
+	ErrRecordTooLarge = errors.New("record too large")
+)
+
+type segment struct {
+	path     string
+	firstLSN uint64
+}
+
+// SegmentInfo describes a segment file. LastLSN is zero for an empty
+// segment and Corruptions lists the damaged records it holds.
+type SegmentInfo struct {
+	Path        string
+	FirstLSN    uint64
+	LastLSN     uint64
+	Records     int
+	Size        int64
+	Corruptions []*Corruption
+}
+
+// Segments scans every segment of the WAL in dir, skipping over damaged
+// records instead of failing on them.
+func Segments(dir string) ([]SegmentInfo, error) {
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]SegmentInfo, 0, len(segments))
+	for _, s := range segments {
+		stat, err := os.Stat(s.path)
+		if err != nil {
+			return nil, err
+		}
+
+		check, err := checkSegment(s.path, true)
+		if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, SegmentInfo{
+			Path:        s.path,
+			FirstLSN:    s.firstLSN,
+			LastLSN:     check.lastLSN,
+			Records:     check.records,
+			Size:        stat.Size(),
+			Corruptions: check.problems,
+		})
+	}
+
+	return infos, nil
+}
+
+func segmentName(firstLSN uint64) string {
+	return fmt.Sprintf("%020d%s", firstLSN, segmentExt)
+}
+
+func listSegments(dir string) ([]segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []segment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+
+		firstLSN, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		segments = append(segments, segment{path: filepath.Join(dir, name), firstLSN: firstLSN})
+	}
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i].firstLSN < segments[j].firstLSN })
+
+	return segments, nil
+}
+
+func encodeRecord(e *Element) ([]byte, error) {
+	payload, err := proto.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) > maxRecordSize {
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", ErrRecordTooLarge, len(payload), maxRecordSize)
+	}
+
+	buffer := make([]byte, recordHeaderSize+len(payload))
+	internal.PutPrefix(buffer, len(payload))
+	binary.BigEndian.PutUint32(buffer[internal.PrefixSize:recordHeaderSize], crc32.Checksum(payload, crcTable))
+	copy(buffer[recordHeaderSize:], payload)
+
+	return buffer, nil
+}
+
+// readRecord reads the next record. It returns io.EOF at a clean end of
+// segment and one of the Err* sentinels, possibly wrapped, when the record
+// is damaged. n is the number of bytes the record occupies.
+func readRecord(r *bufio.Reader) (e *Element, n int, err error) {
+	header := make([]byte, recordHeaderSize)
+	if read, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, 0, io.EOF
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, read, ErrTruncated
+		}
+		return nil, read, err
+	}
+
+	size := internal.ParsePrefix(header)
+	checksum := binary.BigEndian.Uint32(header[internal.PrefixSize:recordHeaderSize])
+	if size == 0 || size > maxRecordSize {
+		return nil, recordHeaderSize, fmt.Errorf("%w: %d", ErrInvalidSize, size)
+	}
+
+	payload := make([]byte, size)
+	if read, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, recordHeaderSize + read, ErrTruncated
+		}
+		return nil, recordHeaderSize + read, err
+	}
+
+	n = recordHeaderSize + int(size)
+	if got := crc32.Checksum(payload, crcTable); got != checksum {
+		return nil, n, fmt.Errorf("%w: got %08x, want %08x", ErrChecksum, got, checksum)
+	}
+
+	e = &Element{}
+	if err := proto.Unmarshal(payload, e); err != nil {
+		return nil, n, fmt.Errorf("%w: %w", ErrInvalidElement, err)
+	}
+
+	return e, n, nil
+}
+
+// resumable reports whether reading can continue after a damaged record,
+// which is the case when its length prefix could still be trusted.
+func resumable(err error) bool {
+	return errors.Is(err, ErrChecksum) || errors.Is(err, ErrInvalidElement)
+}
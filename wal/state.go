@@ -0,0 +1,610 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sevenDatabase/SevenDB-go/wire"
+)
+
+// ErrUnsupportedCommand reports a mutating command the compactor cannot
+// fold, which would make a snapshot incomplete.
+var ErrUnsupportedCommand = errors.New("unsupported command")
+
+// noEffect lists the commands that leave the keyspace untouched.
+var noEffect = map[string]bool{
+	"PING": true, "ECHO": true, "HANDSHAKE": true, "UNWATCH": true, "KEYS": true,
+	"GET": true, "EXISTS": true, "TYPE": true, "TTL": true, "EXPIRETIME": true,
+	"HGET": true, "HGETALL": true,
+	"ZCOUNT": true, "ZRANGE": true, "ZRANK": true, "ZCARD": true,
+	"GEODIST": true, "GEOSEARCH": true, "GEOHASH": true, "GEOPOS": true,
+}
+
+type value struct {
+	str  *string
+	hash map[string]string
+	zset map[string]float64
+	// expireAt is in milliseconds since the Unix epoch, zero when the key
+	// does not expire.
+	expireAt int64
+}
+
+// state is an in-memory keyspace that applies commands the way the server
+// does. Commands the server would reject, like INCR on a non-integer,
+// leave it unchanged since they left the server unchanged too.
+type state struct {
+	keys map[string]*value
+}
+
+func newState() *state {
+	return &state{keys: map[string]*value{}}
+}
+
+// apply folds cmd, issued at now milliseconds since the Unix epoch.
+func (s *state) apply(cmd *wire.Command, now int64) error {
+	name := strings.ToUpper(cmd.Cmd)
+	if noEffect[name] || strings.HasSuffix(name, "WATCH") {
+		return nil
+	}
+
+	args := cmd.Args
+	switch name {
+	case "FLUSHDB":
+		s.keys = map[string]*value{}
+	case "DEL":
+		for _, key := range args {
+			delete(s.keys, key)
+		}
+	case "SET":
+		return s.set(args, now)
+	case "GETSET":
+		if len(args) == 2 && s.isString(args[0], now) {
+			s.keys[args[0]] = &value{str: &args[1]}
+		}
+	case "GETDEL":
+		if len(args) == 1 && s.isString(args[0], now) {
+			delete(s.keys, args[0])
+		}
+	case "GETEX":
+		return s.getex(args, now)
+	case "INCR", "DECR":
+		if len(args) == 1 {
+			delta := int64(1)
+			if name == "DECR" {
+				delta = -1
+			}
+			s.incr(args[0], delta, now)
+		}
+	case "INCRBY", "DECRBY":
+		if len(args) == 2 {
+			delta, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				return nil
+			}
+			if name == "DECRBY" {
+				delta = -delta
+			}
+			s.incr(args[0], delta, now)
+		}
+	case "EXPIRE", "EXPIREAT":
+		return s.expire(name, args, now)
+	case "HSET":
+		if len(args) < 3 || len(args)%2 == 0 {
+			return nil
+		}
+		v := s.get(args[0], now)
+		if v == nil {
+			v = &value{hash: map[string]string{}}
+			s.keys[args[0]] = v
+		}
+		if v.hash == nil {
+			return nil
+		}
+		for i := 1; i < len(args); i += 2 {
+			v.hash[args[i]] = args[i+1]
+		}
+	case "ZADD":
+		return s.zadd(args, now)
+	case "GEOADD":
+		return s.geoadd(args, now)
+	case "ZREM":
+		if len(args) < 2 {
+			return nil
+		}
+		if v := s.get(args[0], now); v != nil && v.zset != nil {
+			for _, member := range args[1:] {
+				delete(v.zset, member)
+			}
+			s.dropEmpty(args[0])
+		}
+	case "ZPOPMIN", "ZPOPMAX":
+		return s.zpop(name == "ZPOPMAX", args, now)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedCommand, name)
+	}
+
+	return nil
+}
+
+// get returns the live value of key, dropping it once it has expired.
+func (s *state) get(key string, now int64) *value {
+	v, ok := s.keys[key]
+	if !ok {
+		return nil
+	}
+
+	if v.expireAt > 0 && v.expireAt <= now {
+		delete(s.keys, key)
+		return nil
+	}
+
+	return v
+}
+
+func (s *state) isString(key string, now int64) bool {
+	v := s.get(key, now)
+	return v == nil || v.str != nil
+}
+
+func (s *state) dropEmpty(key string) {
+	if v := s.keys[key]; (v.hash != nil && len(v.hash) == 0) || (v.zset != nil && len(v.zset) == 0) {
+		delete(s.keys, key)
+	}
+}
+
+// set handles SET key value [NX|XX] [GET] [EX s|PX ms|EXAT s|PXAT ms|KEEPTTL].
+func (s *state) set(args []string, now int64) error {
+	if len(args) < 2 {
+		return nil
+	}
+
+	var nx, xx, keepTTL bool
+	var expireAt int64
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GET":
+		case "KEEPTTL":
+			keepTTL = true
+		case "EX", "PX", "EXAT", "PXAT":
+			if i+1 >= len(args) || !positive(args[i+1]) {
+				return nil
+			}
+			i++
+			at, ok := expiry(opt, args[i], now)
+			if !ok {
+				return nil
+			}
+			expireAt = at
+		default:
+			return fmt.Errorf("%w: SET option %s", ErrUnsupportedCommand, args[i])
+		}
+	}
+
+	existing := s.get(args[0], now)
+	if (nx && existing != nil) || (xx && existing == nil) {
+		return nil
+	}
+
+	v := &value{str: &args[1], expireAt: expireAt}
+	if keepTTL && existing != nil {
+		v.expireAt = existing.expireAt
+	}
+	s.keys[args[0]] = v
+	s.get(args[0], now)
+
+	return nil
+}
+
+// getex handles GETEX key [EX s|PX ms|EXAT s|PXAT ms|PERSIST].
+func (s *state) getex(args []string, now int64) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	v := s.get(args[0], now)
+	if v == nil || v.str == nil || len(args) == 1 {
+		return nil
+	}
+
+	switch opt := strings.ToUpper(args[1]); opt {
+	case "PERSIST":
+		v.expireAt = 0
+	case "EX", "PX", "EXAT", "PXAT":
+		if len(args) < 3 || !positive(args[2]) {
+			return nil
+		}
+		if at, ok := expiry(opt, args[2], now); ok {
+			v.expireAt = at
+			s.get(args[0], now)
+		}
+	default:
+		return fmt.Errorf("%w: GETEX option %s", ErrUnsupportedCommand, args[1])
+	}
+
+	return nil
+}
+
+func (s *state) incr(key string, delta int64, now int64) {
+	v := s.get(key, now)
+	if v == nil {
+		zero := "0"
+		v = &value{str: &zero}
+	}
+	if v.str == nil {
+		return
+	}
+
+	n, err := strconv.ParseInt(*v.str, 10, 64)
+	if err != nil || (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+		return
+	}
+
+	result := strconv.FormatInt(n+delta, 10)
+	s.keys[key] = &value{str: &result, expireAt: v.expireAt}
+}
+
+// expire handles EXPIRE key seconds and EXPIREAT key timestamp, both with an
+// optional NX|XX|GT|LT condition.
+func (s *state) expire(name string, args []string, now int64) error {
+	if len(args) < 2 {
+		return nil
+	}
+
+	v := s.get(args[0], now)
+	if v == nil {
+		return nil
+	}
+
+	opt := "EX"
+	if name == "EXPIREAT" {
+		opt = "EXAT"
+	}
+	at, ok := expiry(opt, args[1], now)
+	if !ok {
+		return nil
+	}
+
+	if len(args) > 2 {
+		switch cond := strings.ToUpper(args[2]); cond {
+		case "NX":
+			ok = v.expireAt == 0
+		case "XX":
+			ok = v.expireAt > 0
+		case "GT":
+			ok = v.expireAt > 0 && at > v.expireAt
+		case "LT":
+			ok = v.expireAt == 0 || at < v.expireAt
+		default:
+			return fmt.Errorf("%w: %s option %s", ErrUnsupportedCommand, name, args[2])
+		}
+		if !ok {
+			return nil
+		}
+	}
+
+	v.expireAt = at
+	s.get(args[0], now)
+
+	return nil
+}
+
+// zadd handles ZADD key [NX|XX] [GT|LT] [CH] [INCR] score member ...
+func (s *state) zadd(args []string, now int64) error {
+	if len(args) < 3 {
+		return nil
+	}
+
+	var nx, xx, gt, lt, incr bool
+	i := 1
+flags:
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GT":
+			gt = true
+		case "LT":
+			lt = true
+		case "CH":
+		case "INCR":
+			incr = true
+		default:
+			break flags
+		}
+	}
+
+	pairs := args[i:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 || (incr && len(pairs) != 2) {
+		return nil
+	}
+
+	scores := make([]float64, 0, len(pairs)/2)
+	for j := 0; j < len(pairs); j += 2 {
+		score, err := strconv.ParseFloat(pairs[j], 64)
+		if err != nil || math.IsNaN(score) {
+			return nil
+		}
+		scores = append(scores, score)
+	}
+
+	v := s.get(args[0], now)
+	if v == nil {
+		v = &value{zset: map[string]float64{}}
+		s.keys[args[0]] = v
+	}
+	if v.zset == nil {
+		return nil
+	}
+
+	for j, score := range scores {
+		member := pairs[2*j+1]
+		current, exists := v.zset[member]
+		if (nx && exists) || (xx && !exists) {
+			continue
+		}
+		if incr && exists {
+			score += current
+		}
+		if exists && ((gt && score <= current) || (lt && score >= current)) {
+			continue
+		}
+		v.zset[member] = score
+	}
+	s.dropEmpty(args[0])
+
+	return nil
+}
+
+// Geo members live in a sorted set scored by the 52-bit geohash of their
+// coordinates, as on the server, so GEOADD folds into ZADD.
+const (
+	geoStep   = 26
+	geoLatMax = 85.05112878
+	geoLonMax = 180
+)
+
+// geoadd handles GEOADD key [NX|XX] [CH] longitude latitude member ...
+func (s *state) geoadd(args []string, now int64) error {
+	if len(args) < 4 {
+		return nil
+	}
+
+	var nx, xx bool
+	i := 1
+flags:
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "CH":
+		default:
+			break flags
+		}
+	}
+
+	triples := args[i:]
+	if len(triples) == 0 || len(triples)%3 != 0 || (nx && xx) {
+		return nil
+	}
+
+	scores := make([]float64, 0, len(triples)/3)
+	for j := 0; j < len(triples); j += 3 {
+		lon, err := strconv.ParseFloat(triples[j], 64)
+		if err != nil || lon < -geoLonMax || lon > geoLonMax {
+			return nil
+		}
+		lat, err := strconv.ParseFloat(triples[j+1], 64)
+		if err != nil || lat < -geoLatMax || lat > geoLatMax {
+			return nil
+		}
+		scores = append(scores, float64(geohash(lon, lat)))
+	}
+
+	v := s.get(args[0], now)
+	if v == nil {
+		v = &value{zset: map[string]float64{}}
+		s.keys[args[0]] = v
+	}
+	if v.zset == nil {
+		return nil
+	}
+
+	for j, score := range scores {
+		member := triples[3*j+2]
+		if _, exists := v.zset[member]; (nx && exists) || (xx && !exists) {
+			continue
+		}
+		v.zset[member] = score
+	}
+	s.dropEmpty(args[0])
+
+	return nil
+}
+
+// geohash interleaves the 26-bit cells of lat and lon, latitude in the even
+// bits.
+func geohash(lon, lat float64) uint64 {
+	latCell := uint32((lat + geoLatMax) / (2 * geoLatMax) * (1 << geoStep))
+	lonCell := uint32((lon + geoLonMax) / (2 * geoLonMax) * (1 << geoStep))
+
+	return spread(latCell) | spread(lonCell)<<1
+}
+
+// spread moves bit i of x to bit 2i.
+func spread(x uint32) uint64 {
+	var out uint64
+	for i := range 32 {
+		out |= uint64(x>>i&1) << (2 * i)
+	}
+
+	return out
+}
+
+// zpop handles ZPOPMIN and ZPOPMAX key [count].
+func (s *state) zpop(max bool, args []string, now int64) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	count := 1
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return nil
+		}
+		count = n
+	}
+
+	v := s.get(args[0], now)
+	if v == nil || v.zset == nil {
+		return nil
+	}
+
+	members := sortedMembers(v.zset)
+	if max {
+		for l, r := 0, len(members)-1; l < r; l, r = l+1, r-1 {
+			members[l], members[r] = members[r], members[l]
+		}
+	}
+
+	for _, member := range members[:min(count, len(members))] {
+		delete(v.zset, member)
+	}
+	s.dropEmpty(args[0])
+
+	return nil
+}
+
+// commands returns the commands that rebuild the state, in key order, after
+// dropping the keys that expired by now.
+func (s *state) commands(now int64) []*wire.Command {
+	keys := make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		if s.get(key, now) != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var cmds []*wire.Command
+	for _, key := range keys {
+		v := s.keys[key]
+		switch {
+		case v.str != nil:
+			args := []string{key, *v.str}
+			if v.expireAt > 0 {
+				args = append(args, "PXAT", strconv.FormatInt(v.expireAt, 10))
+			}
+			cmds = append(cmds, &wire.Command{Cmd: "SET", Args: args})
+			continue
+		case v.hash != nil:
+			fields := make([]string, 0, len(v.hash))
+			for field := range v.hash {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+
+			pairs := make([]string, 0, 2*len(fields))
+			for _, field := range fields {
+				pairs = append(pairs, field, v.hash[field])
+			}
+			cmds = appendBatched(cmds, "HSET", key, pairs)
+		case v.zset != nil:
+			var pairs []string
+			for _, member := range sortedMembers(v.zset) {
+				pairs = append(pairs, strconv.FormatFloat(v.zset[member], 'f', -1, 64), member)
+			}
+			cmds = appendBatched(cmds, "ZADD", key, pairs)
+		}
+
+		if v.expireAt > 0 {
+			// EXPIREAT takes seconds; rounding up never expires a key early.
+			seconds := (v.expireAt + 999) / 1000
+			cmds = append(cmds, &wire.Command{Cmd: "EXPIREAT", Args: []string{key, strconv.FormatInt(seconds, 10)}})
+		}
+	}
+
+	return cmds
+}
+
+// snapshotBatchSize bounds the field and value bytes of a single HSET or
+// ZADD in a snapshot, so a large key neither makes a record larger than
+// readers accept nor a command larger than the server's frames.
+const snapshotBatchSize = 64 * 1024
+
+// appendBatched appends the commands that add pairs to key, as many of them
+// as it takes to keep each within snapshotBatchSize.
+func appendBatched(cmds []*wire.Command, name, key string, pairs []string) []*wire.Command {
+	args, size := []string{key}, 0
+	for i := 0; i < len(pairs); i += 2 {
+		n := len(pairs[i]) + len(pairs[i+1])
+		if len(args) > 1 && size+n > snapshotBatchSize {
+			cmds = append(cmds, &wire.Command{Cmd: name, Args: args})
+			args, size = []string{key}, 0
+		}
+
+		args = append(args, pairs[i], pairs[i+1])
+		size += n
+	}
+
+	return append(cmds, &wire.Command{Cmd: name, Args: args})
+}
+
+// expiry converts an EX, PX, EXAT or PXAT argument into milliseconds since
+// the Unix epoch.
+func expiry(opt, arg string, now int64) (int64, bool) {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	switch opt {
+	case "EX":
+		return now + n*1000, true
+	case "PX":
+		return now + n, true
+	case "EXAT":
+		return n * 1000, true
+	default:
+		return n, true
+	}
+}
+
+// positive reports whether arg is a valid SET or GETEX expiry, which unlike
+// EXPIRE rejects anything below one.
+func positive(arg string) bool {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	return err == nil && n > 0
+}
+
+// sortedMembers orders members by score, then lexicographically.
+func sortedMembers(zset map[string]float64) []string {
+	members := make([]string, 0, len(zset))
+	for member := range zset {
+		members = append(members, member)
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		if zset[members[i]] != zset[members[j]] {
+			return zset[members[i]] < zset[members[j]]
+		}
+		return members[i] < members[j]
+	})
+
+	return members
+}
@@ -0,0 +1,507 @@
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+func appendN(t *testing.T, w *Writer, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		if _, err := w.Append(&Element{
+			ElementType: ElementType_ELEMENT_TYPE_COMMAND,
+			Payload:     []byte(fmt.Sprintf("payload-%d", i)),
+		}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+}
+
+func readAll(t *testing.T, dir string, fromLSN uint64) []*Element {
+	t.Helper()
+
+	r, err := NewReader(dir, fromLSN)
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	defer r.Close()
+
+	var elements []*Element
+	for {
+		e, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return elements
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		elements = append(elements, e)
+	}
+}
+
+func TestWriterRotatesSegments(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, Options{SegmentSize: 128, SyncPolicy: SyncNever})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+
+	// act
+	appendN(t, w, 20)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// assert
+	segments, err := listSegments(dir)
+	if err != nil {
+		t.Fatalf("listSegments() error = %v", err)
+	}
+	if len(segments) < 2 {
+		t.Errorf("got %d segments, want rotation into several", len(segments))
+	}
+
+	elements := readAll(t, dir, 0)
+	if len(elements) != 20 {
+		t.Fatalf("read %d elements, want 20", len(elements))
+	}
+	for i, e := range elements {
+		if e.Lsn != uint64(i+1) {
+			t.Errorf("element %d has lsn %d, want %d", i, e.Lsn, i+1)
+		}
+	}
+}
+
+func TestReaderStartsFromLSN(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, Options{SegmentSize: 128, SyncPolicy: SyncAlways})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	appendN(t, w, 20)
+	w.Close()
+
+	// act
+	elements := readAll(t, dir, 13)
+
+	// assert
+	if len(elements) != 8 || elements[0].Lsn != 13 {
+		t.Errorf("read %d elements starting at %v, want 8 starting at lsn 13", len(elements), elements[0])
+	}
+}
+
+func TestWriterContinuesAfterReopen(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, Options{SyncPolicy: SyncInterval})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	appendN(t, w, 3)
+	w.Close()
+
+	// act
+	w, err = OpenWriter(dir, Options{})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	defer w.Close()
+	lsn, err := w.Append(&Element{ElementType: ElementType_ELEMENT_TYPE_NOOP})
+
+	// assert
+	if err != nil || lsn != 4 {
+		t.Errorf("Append() = %d, %v, want lsn 4", lsn, err)
+	}
+}
+
+func TestWriterRejectsNonMonotonicLSN(t *testing.T) {
+	// arrange
+	w, err := OpenWriter(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	defer w.Close()
+	appendN(t, w, 2)
+
+	// act
+	_, err = w.Append(&Element{Lsn: 2})
+
+	// assert
+	if err == nil {
+		t.Errorf("Append() with lsn 2 after lsn 2 succeeded, want error")
+	}
+}
+
+func TestWriterRejectsOversizedRecords(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, Options{})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	defer w.Close()
+	appendN(t, w, 1)
+
+	// act
+	_, err = w.Append(&Element{Payload: make([]byte, maxRecordSize)})
+	appendN(t, w, 1)
+
+	// assert
+	if !errors.Is(err, ErrRecordTooLarge) {
+		t.Errorf("Append() error = %v, want ErrRecordTooLarge", err)
+	}
+
+	elements := readAll(t, dir, 0)
+	if len(elements) != 2 || elements[1].Lsn != 2 {
+		t.Errorf("read %d elements after the rejected one, want lsns 1 and 2", len(elements))
+	}
+}
+
+// writeSegment writes n records into a single segment and returns its path
+// and the offset at which every record starts.
+func writeSegment(t *testing.T, dir string, n int) (string, []int64) {
+	t.Helper()
+
+	w, err := OpenWriter(dir, Options{SyncPolicy: SyncNever})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+
+	var offsets []int64
+	for i := 0; i < n; i++ {
+		offsets = append(offsets, w.size)
+		appendN(t, w, 1)
+	}
+	w.Close()
+
+	segments, err := listSegments(dir)
+	if err != nil || len(segments) != 1 {
+		t.Fatalf("listSegments() = %v, %v, want one segment", segments, err)
+	}
+
+	return segments[0].path, offsets
+}
+
+func corruptByte(t *testing.T, path string, offset int64) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	data[offset] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func tearTail(t *testing.T, path string, bytes int64) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if err := os.Truncate(path, info.Size()-bytes); err != nil {
+		t.Fatalf("Truncate() error = %v", err)
+	}
+}
+
+func TestRecoveryPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RecoveryPolicy
+		damage  func(t *testing.T, path string, offsets []int64)
+		wantErr error
+		wantLSN []uint64
+	}{
+		{
+			name:   "fail on checksum mismatch",
+			policy: RecoverFail,
+			damage: func(t *testing.T, path string, offsets []int64) {
+				corruptByte(t, path, offsets[2]+recordHeaderSize)
+			},
+			wantErr: ErrChecksum,
+		},
+		{
+			name:   "skip checksum mismatch",
+			policy: RecoverSkip,
+			damage: func(t *testing.T, path string, offsets []int64) {
+				corruptByte(t, path, offsets[2]+recordHeaderSize)
+			},
+			wantLSN: []uint64{1, 2, 4, 5},
+		},
+		{
+			name:   "fail on torn tail",
+			policy: RecoverFail,
+			damage: func(t *testing.T, path string, offsets []int64) {
+				tearTail(t, path, 3)
+			},
+			wantErr: ErrTruncated,
+		},
+		{
+			name:   "skip torn tail",
+			policy: RecoverSkip,
+			damage: func(t *testing.T, path string, offsets []int64) {
+				tearTail(t, path, 3)
+			},
+			wantLSN: []uint64{1, 2, 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			dir := t.TempDir()
+			path, offsets := writeSegment(t, dir, 5)
+			tt.damage(t, path, offsets)
+
+			// act
+			r, err := NewReader(dir, 0, WithRecovery(tt.policy))
+
+			// assert
+			if tt.wantErr != nil {
+				var corruption *Corruption
+				if !errors.As(err, &corruption) || !errors.Is(err, tt.wantErr) || corruption.Segment != path {
+					t.Fatalf("NewReader() error = %v, want corruption %v in %s", err, tt.wantErr, path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			defer r.Close()
+
+			if len(r.Corruptions()) != 1 {
+				t.Errorf("Corruptions() = %v, want one", r.Corruptions())
+			}
+
+			var got []uint64
+			for {
+				e, err := r.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Next() error = %v", err)
+				}
+				got = append(got, e.Lsn)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantLSN) {
+				t.Errorf("read lsns %v, want %v", got, tt.wantLSN)
+			}
+		})
+	}
+}
+
+func TestCorruptionReportsOffset(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	path, offsets := writeSegment(t, dir, 3)
+	corruptByte(t, path, offsets[1]+recordHeaderSize)
+
+	// act
+	_, err := NewReader(dir, 0)
+
+	// assert
+	var corruption *Corruption
+	if !errors.As(err, &corruption) || corruption.Offset != offsets[1] {
+		t.Errorf("NewReader() error = %v, want corruption at offset %d", err, offsets[1])
+	}
+}
+
+func TestWriterTruncatesTornTail(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	path, _ := writeSegment(t, dir, 3)
+	tearTail(t, path, 3)
+
+	// act
+	w, err := OpenWriter(dir, Options{Recovery: RecoverTruncate})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	lsn, err := w.Append(&Element{ElementType: ElementType_ELEMENT_TYPE_NOOP})
+	w.Close()
+
+	// assert
+	if err != nil || lsn != 3 {
+		t.Errorf("Append() = %d, %v, want lsn 3 replacing the torn record", lsn, err)
+	}
+
+	if elements := readAll(t, dir, 0); len(elements) != 3 {
+		t.Errorf("read %d elements, want 3", len(elements))
+	}
+}
+
+func TestWriterTruncatesAtDamagedRecord(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, Options{SegmentSize: 128, SyncPolicy: SyncNever})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	appendN(t, w, 20)
+	w.Close()
+
+	segments, err := listSegments(dir)
+	if err != nil || len(segments) < 3 {
+		t.Fatalf("listSegments() = %v, %v, want at least three segments", segments, err)
+	}
+	corruptByte(t, segments[1].path, recordHeaderSize)
+
+	// act
+	w, err = OpenWriter(dir, Options{Recovery: RecoverTruncate})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	lsn, err := w.Append(&Element{ElementType: ElementType_ELEMENT_TYPE_NOOP})
+	w.Close()
+
+	// assert
+	if err != nil || lsn != segments[1].firstLSN {
+		t.Errorf("Append() = %d, %v, want lsn %d replacing the damaged record", lsn, err, segments[1].firstLSN)
+	}
+
+	if elements := readAll(t, dir, 0); uint64(len(elements)) != segments[1].firstLSN {
+		t.Errorf("read %d elements, want %d", len(elements), segments[1].firstLSN)
+	}
+}
+
+func TestWriterSkipKeepsDamagedRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		damage  func(t *testing.T, path string, offsets []int64)
+		wantLSN []uint64
+	}{
+		{
+			name: "checksum mismatch",
+			damage: func(t *testing.T, path string, offsets []int64) {
+				corruptByte(t, path, offsets[1]+recordHeaderSize)
+			},
+			wantLSN: []uint64{1, 3, 4, 5, 6},
+		},
+		{
+			name: "invalid size",
+			damage: func(t *testing.T, path string, offsets []int64) {
+				corruptByte(t, path, offsets[3])
+			},
+			wantLSN: []uint64{1, 2, 3, 4},
+		},
+		{
+			name: "torn tail",
+			damage: func(t *testing.T, path string, offsets []int64) {
+				tearTail(t, path, 3)
+			},
+			wantLSN: []uint64{1, 2, 3, 4, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			dir := t.TempDir()
+			path, offsets := writeSegment(t, dir, 5)
+			tt.damage(t, path, offsets)
+
+			// act
+			w, err := OpenWriter(dir, Options{Recovery: RecoverSkip})
+			if err != nil {
+				t.Fatalf("OpenWriter() error = %v", err)
+			}
+			lsn, err := w.Append(&Element{ElementType: ElementType_ELEMENT_TYPE_NOOP})
+			w.Close()
+
+			// assert
+			want := tt.wantLSN[len(tt.wantLSN)-1]
+			if err != nil || lsn != want {
+				t.Errorf("Append() = %d, %v, want lsn %d", lsn, err, want)
+			}
+
+			r, err := NewReader(dir, 0, WithRecovery(RecoverSkip))
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			defer r.Close()
+
+			var got []uint64
+			for {
+				e, err := r.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Next() error = %v", err)
+				}
+				got = append(got, e.Lsn)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantLSN) {
+				t.Errorf("read lsns %v, want %v", got, tt.wantLSN)
+			}
+		})
+	}
+}
+
+func TestReaderRefusesTruncation(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	path, offsets := writeSegment(t, dir, 3)
+	corruptByte(t, path, offsets[1]+recordHeaderSize)
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+
+	// act
+	_, err = NewReader(dir, 0, WithRecovery(RecoverTruncate))
+
+	// assert
+	if err == nil {
+		t.Errorf("NewReader() with RecoverTruncate succeeded, want error")
+	}
+
+	if after, err := os.Stat(path); err != nil || after.Size() != before.Size() {
+		t.Errorf("segment was truncated by a reader")
+	}
+}
+
+func TestSegments(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, Options{SegmentSize: 128, SyncPolicy: SyncNever})
+	if err != nil {
+		t.Fatalf("OpenWriter() error = %v", err)
+	}
+	appendN(t, w, 20)
+	w.Close()
+
+	// act
+	infos, err := Segments(dir)
+
+	// assert
+	if err != nil {
+		t.Fatalf("Segments() error = %v", err)
+	}
+
+	records := 0
+	next := uint64(1)
+	for _, info := range infos {
+		if info.FirstLSN != next || info.LastLSN != next+uint64(info.Records)-1 {
+			t.Errorf("segment %s covers %d-%d with %d records, want first lsn %d", info.Path, info.FirstLSN, info.LastLSN, info.Records, next)
+		}
+		next = info.LastLSN + 1
+		records += info.Records
+	}
+
+	if len(infos) < 2 || records != 20 {
+		t.Errorf("Segments() = %d segments with %d records, want several segments with 20", len(infos), records)
+	}
+}
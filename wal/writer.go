@@ -0,0 +1,320 @@
+// Copyright (c) 2022-present, DiceDB contributors
+// All rights reserved. Licensed under the BSD 3-Clause License. See LICENSE file in the project root for full license information.
+
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSegmentSize  = 64 * 1024 * 1024 // 64 MB
+	defaultSyncInterval = 100 * time.Millisecond
+)
+
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs after every append.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval fsyncs in the background every Options.SyncInterval.
+	SyncInterval
+	// SyncNever leaves flushing to the operating system.
+	SyncNever
+)
+
+type Options struct {
+	// SegmentSize is the size after which the writer rolls over to a new
+	// segment. A single record larger than this gets a segment of its own.
+	SegmentSize int64
+
+	SyncPolicy   SyncPolicy
+	SyncInterval time.Duration
+
+	// Recovery decides what happens to damaged records found when the
+	// writer opens. RecoverTruncate cuts the log back to the last good record
+	// and appends after it. RecoverSkip keeps the damaged records, only a
+	// torn final record is cut off, and appends after the newest segment's
+	// end, or in a new segment when its rest cannot be read. The next LSN
+	// follows the highest one that can still be read.
+	Recovery RecoveryPolicy
+}
+
+// Writer appends Elements to the segment files of a WAL directory. LSNs are
+// strictly increasing across segments and restarts.
+type Writer struct {
+	dir     string
+	opts    Options
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	lastLSN uint64
+	dirty   bool
+	closed  bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// OpenWriter opens the WAL in dir for appending, creating dir if needed. An
+// existing WAL is continued after its last record.
+func OpenWriter(dir string, opts Options) (*Writer, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = defaultSegmentSize
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = defaultSyncInterval
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	w := &Writer{
+		dir:  dir,
+		opts: opts,
+		done: make(chan struct{}),
+	}
+
+	if err := w.openTail(); err != nil {
+		return nil, err
+	}
+
+	if opts.SyncPolicy == SyncInterval {
+		w.wg.Add(1)
+		go w.syncLoop()
+	}
+
+	return w, nil
+}
+
+// Append writes e to the log. A zero Lsn is replaced with the next LSN and a
+// zero Timestamp with the current time in nanoseconds since the Unix epoch;
+// an explicit Lsn must be greater than every LSN written before.
+func (w *Writer) Append(e *Element) (uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errors.New("wal writer is closed")
+	}
+
+	if e.Lsn == 0 {
+		e.Lsn = w.lastLSN + 1
+	} else if e.Lsn <= w.lastLSN {
+		return 0, fmt.Errorf("lsn %d is not greater than last lsn %d", e.Lsn, w.lastLSN)
+	}
+
+	if e.Timestamp == 0 {
+		e.Timestamp = time.Now().UnixNano()
+	}
+
+	record, err := encodeRecord(e)
+	if err != nil {
+		return 0, err
+	}
+
+	if w.file == nil || (w.size > 0 && w.size+int64(len(record)) > w.opts.SegmentSize) {
+		if err := w.rotate(e.Lsn); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(record)
+	w.size += int64(n)
+	if err != nil {
+		return 0, err
+	}
+
+	w.lastLSN = e.Lsn
+	w.dirty = true
+
+	if w.opts.SyncPolicy == SyncAlways {
+		if err := w.sync(); err != nil {
+			return 0, err
+		}
+	}
+
+	return e.Lsn, nil
+}
+
+func (w *Writer) LastLSN() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.lastLSN
+}
+
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.sync()
+}
+
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+	w.mu.Unlock()
+
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.closeFile()
+}
+
+// openTail positions the writer after the last record of the newest segment.
+func (w *Writer) openTail() error {
+	segments, err := listSegments(w.dir)
+	if err != nil {
+		return err
+	}
+
+	if len(segments) == 0 {
+		return nil
+	}
+
+	if w.opts.Recovery == RecoverTruncate {
+		if segments, _, err = recoverSegments(segments, 0, RecoverTruncate); err != nil {
+			return err
+		}
+	}
+
+	last := segments[len(segments)-1]
+	check, err := checkSegment(last.path, w.opts.Recovery == RecoverSkip)
+	if err != nil {
+		return fmt.Errorf("failed to scan segment %s: %w", last.path, err)
+	}
+
+	w.lastLSN = check.lastLSN
+	if w.lastLSN == 0 && last.firstLSN > 0 {
+		w.lastLSN = last.firstLSN - 1
+	}
+
+	size := check.goodSize
+	if len(check.problems) > 0 {
+		// RecoverTruncate repaired the segments above, which leaves
+		// RecoverSkip.
+		if w.opts.Recovery == RecoverFail {
+			return check.problems[0]
+		}
+
+		tail := check.problems[len(check.problems)-1]
+		switch {
+		case errors.Is(tail.Err, ErrTruncated):
+			// Only the torn final record is cut off.
+			slog.Warn("truncating torn wal record", "segment", last.path, "offset", tail.Offset)
+			if err := os.Truncate(last.path, tail.Offset); err != nil {
+				return err
+			}
+			size = tail.Offset
+		case !resumable(tail.Err):
+			// Readers skip the rest of the segment, so appending to it would
+			// hide the new records too.
+			if w.lastLSN+1 == last.firstLSN {
+				return fmt.Errorf("no record of the newest segment is readable, open with RecoverTruncate: %w", tail)
+			}
+			return w.rotate(w.lastLSN + 1)
+		default:
+			info, err := os.Stat(last.path)
+			if err != nil {
+				return err
+			}
+			size = info.Size()
+		}
+	}
+
+	file, err := os.OpenFile(last.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w.file = file
+	w.size = size
+
+	return nil
+}
+
+func (w *Writer) rotate(firstLSN uint64) error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filepath.Join(w.dir, segmentName(firstLSN)), os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w.file = file
+	w.size = 0
+
+	return syncDir(w.dir)
+}
+
+func (w *Writer) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+
+	if err := w.sync(); err != nil {
+		return err
+	}
+
+	err := w.file.Close()
+	w.file = nil
+
+	return err
+}
+
+func (w *Writer) sync() error {
+	if !w.dirty || w.file == nil {
+		return nil
+	}
+
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	w.dirty = false
+
+	return nil
+}
+
+func (w *Writer) syncLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.opts.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			if err := w.Sync(); err != nil {
+				slog.Error("failed to sync wal segment", "error", err)
+			}
+		}
+	}
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -7,6 +7,13 @@ const (
 	Empty          ErrKind = 2
 	Terminated     ErrKind = 3
 	CorruptMessage ErrKind = 4
+	// DeadlineExceeded reports that a read or write did not complete before
+	// its deadline. The wire is closed, as a frame may have been cut short.
+	DeadlineExceeded ErrKind = 5
+	// ChecksumMismatch reports a frame whose CRC32C trailer does not match
+	// its contents. The wire is closed, as its framing can no longer be
+	// trusted.
+	ChecksumMismatch ErrKind = 6
 )
 
 type WireError struct {